@@ -2,11 +2,13 @@ package api
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
 	"strconv"
 	"testing"
@@ -28,8 +30,7 @@ func TestGetUsers(t *testing.T) {
 
 	ctx := context.Background()
 	testContainer := dbAttributes.PgTestContainerSetup(ctx)
-	schemaFile := "../../../pkg/schema.sql"
-	db := dbAttributes.DbSetup(ctx, testContainer, schemaFile)
+	db := dbAttributes.DbSetup(ctx, testContainer)
 	defer pkg.TearDown(db, testContainer)
 
 	repo := repo.RepositoryHandler{Db: db}
@@ -56,14 +57,15 @@ func TestGetUsers(t *testing.T) {
 		}
 	}
 
-	assertUnSuccessfulReq := func(t testing.TB, expectedErro string, payload model.ResponseMeta) {
+	assertUnSuccessfulReq := func(t testing.TB, resp *httptest.ResponseRecorder, expectedField string) {
 		t.Helper()
-		if expectedErro != payload.Error {
-			t.Errorf("expected error message: %v, got %v", expectedErro, payload.Error)
+		var problem model.Problem
+		if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
 		}
 
-		if payload.Success != "" {
-			t.Errorf("expected no success message, got %v", payload.Success)
+		if problem.Code != CodeInvalidParameter || problem.Field != expectedField {
+			t.Errorf("expected problem: %v %v, got %v %v", CodeInvalidParameter, expectedField, problem.Code, problem.Field)
 		}
 	}
 
@@ -84,6 +86,7 @@ func TestGetUsers(t *testing.T) {
 			cursor       string
 			limit        string
 			code         int
+			field        string
 			expectedResp model.ResponseMeta
 			isSuccess    bool
 		}{
@@ -92,8 +95,8 @@ func TestGetUsers(t *testing.T) {
 				cursor: "invalid cursor",
 				limit:  "20",
 				code:   400,
+				field:  "after",
 				expectedResp: model.ResponseMeta{
-					Error:   "invalid cursor param",
 					Success: "",
 					Data:    model.UsersCursorBasedMetaData{},
 				},
@@ -104,8 +107,8 @@ func TestGetUsers(t *testing.T) {
 				cursor: "50",
 				limit:  "invalid-limit",
 				code:   400,
+				field:  "limit",
 				expectedResp: model.ResponseMeta{
-					Error:   "invalid limit param",
 					Success: "",
 					Data:    model.UsersCursorBasedMetaData{},
 				},
@@ -113,7 +116,7 @@ func TestGetUsers(t *testing.T) {
 			},
 			{
 				name:   "success",
-				cursor: "50",
+				cursor: "",
 				limit:  "10",
 				code:   200,
 				expectedResp: model.ResponseMeta{
@@ -125,9 +128,19 @@ func TestGetUsers(t *testing.T) {
 			},
 		}
 
-		handler := pagination.NewCursorBasedHandler(db)
+		handler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{CursorSecret: "test-secret"})
 		httpController := CursorBasedHttpController{Handler: handler}
 
+		successCursor, err := handler.Users.Codec.Encode(model.Cursor{
+			Resource:  "users",
+			Direction: model.SortDesc,
+			Sort:      "-id",
+			Keys:      []string{"50"},
+		})
+		if err != nil {
+			t.Fatalf("cursor encoding failed with error: %v", err)
+		}
+
 		assertUserData := func(t testing.TB, got interface{}, cursor, limit int) {
 			t.Helper()
 
@@ -166,7 +179,10 @@ func TestGetUsers(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				url := fmt.Sprintf("/users/cursor-based?cursor=%v&limit=%v", tc.cursor, tc.limit)
+				if tc.isSuccess {
+					tc.cursor = successCursor
+				}
+				url := fmt.Sprintf("/users/cursor-based?cursor=%v&limit=%v", url.QueryEscape(tc.cursor), tc.limit)
 
 				req, err := http.NewRequest(http.MethodGet, url, nil)
 				if err != nil {
@@ -180,16 +196,15 @@ func TestGetUsers(t *testing.T) {
 
 				var payload model.ResponseMeta
 				assertStatusCode(t, resp.Code, tc.code)
-				assertDecodedJson(t, resp, &payload)
 
 				if !tc.isSuccess {
-					assertUnSuccessfulReq(t, tc.expectedResp.Error, payload)
+					assertUnSuccessfulReq(t, resp, tc.field)
 				} else {
+					assertDecodedJson(t, resp, &payload)
 					assertSuccessReq(t, tc.expectedResp.Success, payload)
 
-					cursorInt, _ := strconv.Atoi(tc.cursor)
 					limitInt, _ := strconv.Atoi(tc.limit)
-					assertUserData(t, payload.Data, cursorInt, limitInt)
+					assertUserData(t, payload.Data, 50, limitInt)
 				}
 
 			})
@@ -203,27 +218,24 @@ func TestGetUsers(t *testing.T) {
 			page         string
 			limit        string
 			code         int
+			field        string
 			expectedResp model.ResponseMeta
 			isSuccess    bool
 		}{
 			{
-				name:  "invalid page",
-				page:  "invalid-page",
-				limit: "10",
-				code:  400,
-				expectedResp: model.ResponseMeta{
-					Error: "invalid page",
-				},
+				name:      "invalid page",
+				page:      "invalid-page",
+				limit:     "10",
+				code:      400,
+				field:     "page",
 				isSuccess: false,
 			},
 			{
-				name:  "invalid limit",
-				page:  "5",
-				limit: "invalid-limit",
-				code:  400,
-				expectedResp: model.ResponseMeta{
-					Error: "invalid limit",
-				},
+				name:      "invalid limit",
+				page:      "5",
+				limit:     "invalid-limit",
+				code:      400,
+				field:     "limit",
 				isSuccess: false,
 			},
 			{
@@ -240,7 +252,7 @@ func TestGetUsers(t *testing.T) {
 			},
 		}
 
-		repoHandler := pagination.NewLimitOffSetHandler(db)
+		repoHandler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{})
 		httpControler := LimitOffsetHttpControler{Handler: repoHandler}
 
 		for _, tc := range testCases {
@@ -258,11 +270,11 @@ func TestGetUsers(t *testing.T) {
 
 				assertStatusCode(t, resp.Code, tc.code)
 				var payload model.ResponseMeta
-				assertDecodedJson(t, resp, &payload)
 
 				if !tc.isSuccess {
-					assertUnSuccessfulReq(t, tc.expectedResp.Error, payload)
+					assertUnSuccessfulReq(t, resp, tc.field)
 				} else {
+					assertDecodedJson(t, resp, &payload)
 					assertSuccessReq(t, tc.expectedResp.Success, payload)
 				}
 
@@ -271,4 +283,60 @@ func TestGetUsers(t *testing.T) {
 
 	})
 
+	t.Run("export", func(t *testing.T) {
+		httpControler := NewExportHttpController(pagination.NewExportHandler(db, 100))
+
+		export := func(t testing.TB, accept, query string) *httptest.ResponseRecorder {
+			t.Helper()
+			req, err := http.NewRequest(http.MethodGet, "users/export"+query, nil)
+			if err != nil {
+				t.Fatalf("request creation failed with error: %v", err)
+			}
+			req.Header.Set("Accept", accept)
+
+			resp := httptest.NewRecorder()
+			httpControler.GetUsers(resp, req)
+			return resp
+		}
+
+		t.Run("ndjson", func(t *testing.T) {
+			resp := export(t, "application/x-ndjson", "")
+			assertStatusCode(t, resp.Code, 200)
+
+			decoder := json.NewDecoder(resp.Body)
+			var count int
+			for decoder.More() {
+				var user model.UserData
+				if err := decoder.Decode(&user); err != nil {
+					t.Fatalf("Error decoding JSON line: %v", err)
+				}
+				count++
+			}
+			if count != 1000 {
+				t.Errorf("expected exported users: %v, got %v", 1000, count)
+			}
+		})
+
+		t.Run("csv", func(t *testing.T) {
+			resp := export(t, "text/csv", "")
+			assertStatusCode(t, resp.Code, 200)
+
+			records, err := csv.NewReader(resp.Body).ReadAll()
+			if err != nil {
+				t.Fatalf("Error decoding CSV: %v", err)
+			}
+			if !reflect.DeepEqual(records[0], []string{"id", "name", "surname"}) {
+				t.Errorf("expected header: %v, got %v", []string{"id", "name", "surname"}, records[0])
+			}
+			if len(records) != 1001 {
+				t.Errorf("expected records: %v, got %v", 1001, len(records))
+			}
+		})
+
+		t.Run("unsupported format", func(t *testing.T) {
+			resp := export(t, "application/xml", "")
+			assertStatusCode(t, resp.Code, 406)
+		})
+	})
+
 }
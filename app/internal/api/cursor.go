@@ -1,24 +1,29 @@
 package api
 
 import (
-	"log"
 	"net/http"
-	"strconv"
+	"slices"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 )
 
 type CursorBasedHttpController struct {
 	Handler pagination.CursorBasedHandler
+	Policy  pagination.Policy
 }
 
-func NewCursorBasedHttpController(repo pagination.CursorBasedHandler) CursorBasedHttpController {
+func NewCursorBasedHttpController(repo pagination.CursorBasedHandler, policy pagination.Policy) CursorBasedHttpController {
 	return CursorBasedHttpController{
 		Handler: repo,
+		Policy:  policy,
 	}
 }
 
+// cursorParams are the query params GetUsers reads besides the filters.
+var cursorParams = []string{"after", "before", "cursor", "limit", "sort", "snapshot", "session"}
+
 func (h CursorBasedHttpController) GetUsers(w http.ResponseWriter, r *http.Request) {
 	// tracer span instance
 	tracerHander := pkg.TracerConfigHandler{}
@@ -27,31 +32,52 @@ func (h CursorBasedHttpController) GetUsers(w http.ResponseWriter, r *http.Reque
 
 	// query params handling
 	query_params := r.URL.Query()
-	cursorStr := query_params.Get("cursor")
+	afterStr := query_params.Get("after")
+	beforeStr := query_params.Get("before")
 	limitStr := query_params.Get("limit")
+	sortStr := query_params.Get("sort")
+	sessionStr := query_params.Get("session")
+
+	// `cursor` is kept as an alias of `after` for existing clients
+	if afterStr == "" {
+		afterStr = query_params.Get("cursor")
+	}
+
+	if err := h.Policy.CheckParams(query_params, slices.Concat(cursorParams, pagination.FilterParams(repo.UserFilterColumns))); err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
 
-	var d interface{}
-	cursorInt, err := strconv.Atoi(cursorStr)
+	limitInt, adjusted, err := h.Policy.ParseLimit("limit", limitStr)
 	if err != nil {
-		JSONResponse(w, http.StatusBadRequest, d, "invalid cursor param", "")
+		errorResponse(ctx, w, r, err)
 		return
 	}
+	applyAdjustments(w, r, adjusted)
 
-	limitInt, err := strconv.Atoi(limitStr)
+	snapshot, err := parseSnapshot(query_params.Get("snapshot"))
 	if err != nil {
-		JSONResponse(w, http.StatusBadRequest, d, "invalid limit param", "")
+		errorResponse(ctx, w, r, &pagination.ParamError{Param: "snapshot", Err: pagination.ErrInvalidSnapshot})
 		return
 	}
 
 	// domain layer
-	result, err := h.Handler.Retrieve(ctx, cursorInt, limitInt)
+	result, err := h.Handler.Retrieve(ctx, pagination.CursorRequest{
+		After:    afterStr,
+		Before:   beforeStr,
+		Sort:     sortStr,
+		Filters:  pagination.ParseFilters(query_params, repo.UserFilterColumns),
+		Limit:    limitInt,
+		Snapshot: snapshot,
+		Session:  sessionStr,
+	})
 	if err != nil {
-		JSONResponse(w, http.StatusInternalServerError, d, "something went wrong, please try agian", "")
-		span.RecordError(err) // Record error in span
-		log.Printf("GetUsers Controller failed with error: %v", err)
+		errorResponse(ctx, w, r, err)
 		return
 	}
 
+	result.Links = cursorLinks(r, result)
+	setLinkHeader(w, result.Links)
 	JSONResponse(w, http.StatusOK, result, "", "retrieved successfully")
 	return
 }
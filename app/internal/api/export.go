@@ -0,0 +1,186 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// export media types
+const (
+	ndjsonMediaType = "application/x-ndjson"
+	csvMediaType    = "text/csv"
+)
+
+type ExportHttpController struct {
+	Handler pagination.ExportHandler
+}
+
+func NewExportHttpController(handler pagination.ExportHandler) ExportHttpController {
+	return ExportHttpController{
+		Handler: handler,
+	}
+}
+
+// GetUsers streams every user matching the filter params as NDJSON or CSV,
+// whichever the Accept header asks for. Every batch is flushed to the client
+// as soon as it is written, a client going away cancels the export.
+func (h ExportHttpController) GetUsers(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "export-httpController", "controller: get-users")
+	defer span.End()
+
+	mediaType, ok := exportMediaType(r.Header.Get("Accept"))
+	if !ok {
+		errorResponse(ctx, w, r, errNotAcceptable)
+		return
+	}
+
+	bw := bufio.NewWriter(w)
+	writeBatch := ndjsonBatchWriter(bw)
+	if mediaType == csvMediaType {
+		writeBatch = csvBatchWriter(bw)
+	}
+
+	started := false
+	err := h.Handler.Export(ctx, pagination.ParseFilters(r.URL.Query(), repo.UserFilterColumns), func(users model.UsersData) error {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", mediaType)
+			w.WriteHeader(http.StatusOK)
+		}
+
+		if err := writeBatch(users); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		return nil
+	})
+
+	if err != nil {
+		// the status is already sent once rows were streamed, the client
+		// only sees a truncated body
+		if started {
+			span.RecordError(err)
+			return
+		}
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	if !started {
+		// no rows matched, send an empty export
+		w.Header().Set("Content-Type", mediaType)
+		w.WriteHeader(http.StatusOK)
+		if err := writeBatch(nil); err == nil {
+			bw.Flush()
+		}
+	}
+}
+
+// exportMediaType picks the export format of an Accept header, NDJSON when
+// there is none. Every format takes the q-value of the most specific media
+// range matching it, the format of the highest non zero q-value wins and
+// ties go to the format whose range is listed first.
+func exportMediaType(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ndjsonMediaType, true
+	}
+
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if raw, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(raw, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+
+	// the ranges matching every format, most specific first
+	formats := []struct {
+		mediaType string
+		matches   [][]string
+	}{
+		{mediaType: ndjsonMediaType, matches: [][]string{{ndjsonMediaType, "application/ndjson"}, {"application/*"}, {"*/*"}}},
+		{mediaType: csvMediaType, matches: [][]string{{csvMediaType}, {"text/*"}, {"*/*"}}},
+	}
+
+	best, bestQ, bestAt := "", 0.0, len(ranges)
+	for _, format := range formats {
+		for _, matches := range format.matches {
+			at := slices.IndexFunc(ranges, func(r mediaRange) bool {
+				return slices.Contains(matches, r.mediaType)
+			})
+			if at < 0 {
+				continue
+			}
+			if q := ranges[at].q; q > bestQ || q == bestQ && q > 0 && at < bestAt {
+				best, bestQ, bestAt = format.mediaType, q, at
+			}
+			break
+		}
+	}
+	return best, best != ""
+}
+
+// ndjsonBatchWriter writes every user of a batch as one JSON line.
+func ndjsonBatchWriter(w *bufio.Writer) func(model.UsersData) error {
+	encoder := json.NewEncoder(w)
+	return func(users model.UsersData) error {
+		for _, user := range users {
+			if err := encoder.Encode(user); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// csvBatchWriter writes every user of a batch as one CSV record, the first
+// call writes the header record.
+func csvBatchWriter(w *bufio.Writer) func(model.UsersData) error {
+	writer := csv.NewWriter(w)
+	header := true
+	return func(users model.UsersData) error {
+		if header {
+			header = false
+			if err := writer.Write([]string{"id", "name", "surname"}); err != nil {
+				return err
+			}
+		}
+		for _, user := range users {
+			if err := writer.Write([]string{strconv.Itoa(user.ID), user.Name, user.Surname}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import "testing"
+
+func TestExportMediaType(t *testing.T) {
+	testCases := []struct {
+		name      string
+		accept    string
+		mediaType string
+		ok        bool
+	}{
+		{name: "no accept header", accept: "", mediaType: ndjsonMediaType, ok: true},
+		{name: "ndjson", accept: "application/x-ndjson", mediaType: ndjsonMediaType, ok: true},
+		{name: "csv", accept: "text/csv", mediaType: csvMediaType, ok: true},
+		{name: "any", accept: "*/*", mediaType: ndjsonMediaType, ok: true},
+		{name: "listed order breaks ties", accept: "text/csv, application/x-ndjson", mediaType: csvMediaType, ok: true},
+		{name: "refused csv", accept: "text/csv;q=0, application/x-ndjson", mediaType: ndjsonMediaType, ok: true},
+		{name: "preferred ndjson", accept: "text/csv;q=0.1, application/x-ndjson;q=0.9", mediaType: ndjsonMediaType, ok: true},
+		{name: "preferred csv", accept: "application/x-ndjson;q=0.5, text/*;q=0.8", mediaType: csvMediaType, ok: true},
+		{name: "specific range overrides any", accept: "application/x-ndjson;q=0, */*", mediaType: csvMediaType, ok: true},
+		{name: "everything refused", accept: "text/csv;q=0, */*;q=0", ok: false},
+		{name: "invalid q-value ignored", accept: "text/csv;q=2, application/x-ndjson;q=0.2", mediaType: ndjsonMediaType, ok: true},
+		{name: "unsupported format", accept: "application/xml", ok: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mediaType, ok := exportMediaType(tc.accept)
+			if mediaType != tc.mediaType || ok != tc.ok {
+				t.Errorf("expected media type: %q %v, got %q %v", tc.mediaType, tc.ok, mediaType, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,373 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// DefaultConnectionSize is the number of edges of a connection requested
+// without first or last.
+const DefaultConnectionSize = 20
+
+var (
+	errConnectionDirection = errors.New("paginate forward with first and after or backward with last and before")
+	errConnectionSize      = errors.New("first and last must not be negative")
+)
+
+// GraphQLHttpController serves the GraphQL API, its users field pages
+// through users as a Relay connection.
+type GraphQLHttpController struct {
+	Schema graphql.Schema
+}
+
+// graphQLRequest is the body of a GraphQL request.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// user connection values resolved by the schema
+type userConnection struct {
+	Edges    []userEdge `graphql:"edges"`
+	PageInfo pageInfo   `graphql:"pageInfo"`
+	filters  []model.Filter
+}
+
+type userEdge struct {
+	Cursor string   `graphql:"cursor"`
+	Node   userNode `graphql:"node"`
+}
+
+type userNode struct {
+	ID      int    `graphql:"id"`
+	Name    string `graphql:"name"`
+	Surname string `graphql:"surname"`
+}
+
+type pageInfo struct {
+	HasNextPage     bool    `graphql:"hasNextPage"`
+	HasPreviousPage bool    `graphql:"hasPreviousPage"`
+	StartCursor     *string `graphql:"startCursor"`
+	EndCursor       *string `graphql:"endCursor"`
+}
+
+// NewGraphQLHttpController builds the GraphQL schema on top of the cursor
+// based handler, which reads the edges, and the limit-offset handler, which
+// counts them. The policy bounds the first and last arguments, connections
+// asking for neither hold DefaultConnectionSize edges unless it sets a
+// default page size.
+func NewGraphQLHttpController(cursorHandler pagination.CursorBasedHandler, limitOffsetHandler pagination.LimitOffSetHandler, policy pagination.Policy) (GraphQLHttpController, error) {
+	policy = pagination.Policy{DefaultLimit: DefaultConnectionSize}.Override(policy)
+
+	userType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "User",
+		Fields: graphql.Fields{
+			"id":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+			"name":    &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"surname": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		},
+	})
+
+	edgeType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserEdge",
+		Fields: graphql.Fields{
+			"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"node":   &graphql.Field{Type: graphql.NewNonNull(userType)},
+		},
+	})
+
+	pageInfoType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "PageInfo",
+		Fields: graphql.Fields{
+			"hasNextPage":     &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"hasPreviousPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+			"startCursor":     &graphql.Field{Type: graphql.String},
+			"endCursor":       &graphql.Field{Type: graphql.String},
+		},
+	})
+
+	connectionType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "UserConnection",
+		Fields: graphql.Fields{
+			"edges":    &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(edgeType)))},
+			"pageInfo": &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+			"totalCount": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.Int),
+				// only counted when asked for
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					connection := p.Source.(userConnection)
+					count, err := limitOffsetHandler.CountUsers(p.Context, connection.filters)
+					return count, graphQLError(err)
+				},
+			},
+		},
+	})
+
+	orderFieldType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "UserOrderField",
+		Values: graphql.EnumValueConfigMap{
+			"ID":      &graphql.EnumValueConfig{Value: "id"},
+			"NAME":    &graphql.EnumValueConfig{Value: "name"},
+			"SURNAME": &graphql.EnumValueConfig{Value: "surname"},
+		},
+	})
+
+	directionType := graphql.NewEnum(graphql.EnumConfig{
+		Name: "OrderDirection",
+		Values: graphql.EnumValueConfigMap{
+			"ASC":  &graphql.EnumValueConfig{Value: model.SortAsc},
+			"DESC": &graphql.EnumValueConfig{Value: model.SortDesc},
+		},
+	})
+
+	orderType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserOrder",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"field":     &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(orderFieldType)},
+			"direction": &graphql.InputObjectFieldConfig{Type: directionType, DefaultValue: model.SortAsc},
+		},
+	})
+
+	stringFilterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "StringFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			model.FilterEq:       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			model.FilterPrefix:   &graphql.InputObjectFieldConfig{Type: graphql.String},
+			model.FilterContains: &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+
+	filterType := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name: "UserFilter",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"name":    &graphql.InputObjectFieldConfig{Type: stringFilterType},
+			"surname": &graphql.InputObjectFieldConfig{Type: stringFilterType},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"users": &graphql.Field{
+				Type: graphql.NewNonNull(connectionType),
+				Args: graphql.FieldConfigArgument{
+					"first":   &graphql.ArgumentConfig{Type: graphql.Int},
+					"after":   &graphql.ArgumentConfig{Type: graphql.String},
+					"last":    &graphql.ArgumentConfig{Type: graphql.Int},
+					"before":  &graphql.ArgumentConfig{Type: graphql.String},
+					"orderBy": &graphql.ArgumentConfig{Type: graphql.NewList(graphql.NewNonNull(orderType))},
+					"filter":  &graphql.ArgumentConfig{Type: filterType},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return resolveUsers(p, cursorHandler, policy)
+				},
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		return GraphQLHttpController{}, err
+	}
+	return GraphQLHttpController{Schema: schema}, nil
+}
+
+// Query executes a GraphQL query sent as a JSON POST body or, for GET
+// requests, in the query, operationName and variables params.
+func (h GraphQLHttpController) Query(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "graphql-httpController", "controller: query")
+	defer span.End()
+
+	var req graphQLRequest
+	if r.Method == http.MethodGet {
+		url := r.URL.Query()
+		req.Query = url.Get("query")
+		req.OperationName = url.Get("operationName")
+		if variables := url.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				graphQLResponse(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.New("invalid variables"))})
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		graphQLResponse(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(errors.New("invalid request body"))})
+		return
+	}
+
+	warnings := &graphQLWarnings{}
+	result := graphql.Do(graphql.Params{
+		Schema:         h.Schema,
+		RequestString:  req.Query,
+		VariableValues: req.Variables,
+		OperationName:  req.OperationName,
+		Context:        context.WithValue(ctx, graphQLWarningsKey{}, warnings),
+	})
+	applyAdjustments(w, r, warnings.adjustments...)
+	graphQLResponse(w, http.StatusOK, result)
+}
+
+// graphQLResponse writes a GraphQL result, which carries its own errors
+// instead of the ResponseMeta envelope.
+func graphQLResponse(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type graphQLWarningsKey struct{}
+
+// graphQLWarnings collects the arguments the policy clamped while a query
+// was resolved, the response reports them in Warning headers.
+type graphQLWarnings struct {
+	mu          sync.Mutex
+	adjustments []*pagination.Adjustment
+}
+
+// addGraphQLWarning records a clamped argument of the query of the context.
+func addGraphQLWarning(ctx context.Context, adjusted *pagination.Adjustment) {
+	warnings, ok := ctx.Value(graphQLWarningsKey{}).(*graphQLWarnings)
+	if !ok || adjusted == nil {
+		return
+	}
+
+	warnings.mu.Lock()
+	defer warnings.mu.Unlock()
+	warnings.adjustments = append(warnings.adjustments, adjusted)
+}
+
+// resolveUsers reads the users connection through the cursor based handler.
+func resolveUsers(p graphql.ResolveParams, handler pagination.CursorBasedHandler, policy pagination.Policy) (interface{}, error) {
+	first, hasFirst := p.Args["first"].(int)
+	last, hasLast := p.Args["last"].(int)
+	after, _ := p.Args["after"].(string)
+	before, _ := p.Args["before"].(string)
+
+	if (hasFirst || after != "") && (hasLast || before != "") {
+		return nil, graphQLError(errConnectionDirection)
+	}
+	if first < 0 {
+		return nil, graphQLError(&pagination.ParamError{Param: "first", Err: errConnectionSize})
+	}
+	if last < 0 {
+		return nil, graphQLError(&pagination.ParamError{Param: "last", Err: errConnectionSize})
+	}
+
+	req := pagination.CursorRequest{
+		After:   after,
+		Before:  before,
+		Sort:    connectionSort(p.Args["orderBy"]),
+		Filters: connectionFilters(p.Args["filter"]),
+		Limit:   policy.PageSize(),
+		FromEnd: hasLast,
+	}
+
+	var adjusted *pagination.Adjustment
+	var err error
+	if hasFirst {
+		req.Limit, adjusted, err = policy.Limit("first", first)
+	}
+	if hasLast {
+		req.Limit, adjusted, err = policy.Limit("last", last)
+	}
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+	addGraphQLWarning(p.Context, adjusted)
+
+	page, err := handler.Retrieve(p.Context, req)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+
+	cursors, err := handler.EdgeCursors(req, page.Users)
+	if err != nil {
+		return nil, graphQLError(err)
+	}
+
+	connection := userConnection{
+		Edges:   make([]userEdge, 0, len(page.Users)),
+		filters: req.Filters,
+		PageInfo: pageInfo{
+			HasNextPage:     page.NextCursor != nil,
+			HasPreviousPage: page.PrevCursor != nil,
+		},
+	}
+	for i, user := range page.Users {
+		connection.Edges = append(connection.Edges, userEdge{
+			Cursor: cursors[i],
+			Node:   userNode{ID: user.ID, Name: user.Name, Surname: user.Surname},
+		})
+	}
+	if len(cursors) > 0 {
+		connection.PageInfo.StartCursor = &cursors[0]
+		connection.PageInfo.EndCursor = &cursors[len(cursors)-1]
+	}
+	return connection, nil
+}
+
+// connectionSort renders the orderBy argument as a sort param.
+func connectionSort(arg interface{}) string {
+	orders, _ := arg.([]interface{})
+
+	var sort string
+	for _, order := range orders {
+		fields, _ := order.(map[string]interface{})
+		column, _ := fields["field"].(string)
+		if fields["direction"] == model.SortDesc {
+			column = "-" + column
+		}
+
+		if sort != "" {
+			sort += ","
+		}
+		sort += column
+	}
+	return sort
+}
+
+// connectionFilters collects the filters of the filter argument.
+func connectionFilters(arg interface{}) []model.Filter {
+	columns, _ := arg.(map[string]interface{})
+
+	var filters []model.Filter
+	for _, column := range repo.UserFilterColumns {
+		ops, _ := columns[column].(map[string]interface{})
+		for _, op := range []string{model.FilterEq, model.FilterPrefix, model.FilterContains} {
+			if value, ok := ops[op].(string); ok && value != "" {
+				filters = append(filters, model.Filter{Column: column, Op: op, Value: value})
+			}
+		}
+	}
+	return filters
+}
+
+// graphQLError passes client errors through with their problem code and
+// hides any other error.
+func graphQLError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	problem, kind, known := problemOf(err)
+	if !known {
+		log.Printf("GraphQL resolver failed with error: %v", err)
+	}
+	return problemError{message: kind.err.Error(), problem: problem}
+}
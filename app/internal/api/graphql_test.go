@@ -0,0 +1,169 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestGraphQLUsers(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	cursorHandler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{CursorSecret: "secret"})
+	limitOffsetHandler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{})
+	httpController, err := NewGraphQLHttpController(cursorHandler, limitOffsetHandler, pagination.Policy{})
+	if err != nil {
+		t.Fatalf("schema creation failed with error: %v", err)
+	}
+
+	type connection struct {
+		Edges []struct {
+			Cursor string
+			Node   struct {
+				ID      int
+				Name    string
+				Surname string
+			}
+		}
+		PageInfo struct {
+			HasNextPage     bool
+			HasPreviousPage bool
+			StartCursor     *string
+			EndCursor       *string
+		}
+		TotalCount int
+	}
+
+	query := func(t testing.TB, body string) (connection, []map[string]any) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodPost, "/graphql", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		httpController.Query(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Fatalf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+
+		var payload struct {
+			Data   struct{ Users connection }
+			Errors []map[string]any
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		return payload.Data.Users, payload.Errors
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("first page with count", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname FROM users WHERE name LIKE $1 ORDER BY surname ASC, id ASC LIMIT $2;").
+			WithArgs("Ja%", 3).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).
+				AddRow(4, "Jane", "Adams").
+				AddRow(2, "Jack", "Brown").
+				AddRow(9, "Jade", "Clark"))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users WHERE name LIKE $1").
+			WithArgs("Ja%").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(7))
+
+		got, errs := query(t, `{"query": "{ users(first: 2, orderBy: [{field: SURNAME}], filter: {name: {prefix: \"Ja\"}}) { edges { cursor node { id name surname } } pageInfo { hasNextPage hasPreviousPage startCursor endCursor } totalCount } }"}`)
+		if len(errs) > 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+
+		if len(got.Edges) != 2 || got.Edges[0].Node.ID != 4 || got.Edges[1].Node.ID != 2 {
+			t.Errorf("expected nodes 4 and 2, got %+v", got.Edges)
+		}
+		if !got.PageInfo.HasNextPage || got.PageInfo.HasPreviousPage {
+			t.Errorf("expected a next page only, got %+v", got.PageInfo)
+		}
+		if got.PageInfo.EndCursor == nil || *got.PageInfo.EndCursor != got.Edges[1].Cursor {
+			t.Errorf("expected end cursor: %v, got %v", got.Edges[1].Cursor, got.PageInfo.EndCursor)
+		}
+		if got.TotalCount != 7 {
+			t.Errorf("expected total count: %v, got %v", 7, got.TotalCount)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("last page", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname FROM users ORDER BY id ASC LIMIT $1;").
+			WithArgs(6).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).
+				AddRow(1, "Jane", "Adams").
+				AddRow(2, "Jack", "Brown"))
+
+		got, errs := query(t, `{"query": "query($last: Int) { users(last: $last) { edges { node { id } } pageInfo { hasNextPage hasPreviousPage } } }", "variables": {"last": 5}}`)
+		if len(errs) > 0 {
+			t.Fatalf("expected no errors, got %v", errs)
+		}
+
+		// newest first, so the last page holds the oldest users
+		if len(got.Edges) != 2 || got.Edges[0].Node.ID != 2 || got.Edges[1].Node.ID != 1 {
+			t.Errorf("expected nodes 2 and 1, got %+v", got.Edges)
+		}
+		if got.PageInfo.HasNextPage || got.PageInfo.HasPreviousPage {
+			t.Errorf("expected a single page, got %+v", got.PageInfo)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			body  string
+			error string
+			field string
+		}{
+			{
+				name:  "both directions",
+				body:  `{"query": "{ users(first: 2, last: 2) { edges { cursor } } }"}`,
+				error: errConnectionDirection.Error(),
+			},
+			{
+				name:  "negative size",
+				body:  `{"query": "{ users(first: -1) { edges { cursor } } }"}`,
+				error: errConnectionSize.Error(),
+				field: "first",
+			},
+			{
+				name:  "tampered cursor",
+				body:  `{"query": "{ users(after: \"tampered\") { edges { cursor } } }"}`,
+				error: pagination.ErrInvalidCursor.Error(),
+				field: "after",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				_, errs := query(t, tc.body)
+				if len(errs) != 1 || errs[0]["message"] != tc.error {
+					t.Fatalf("expected error: %v, got %v", tc.error, errs)
+				}
+
+				extensions, _ := errs[0]["extensions"].(map[string]any)
+				if extensions["code"] != CodeInvalidParameter || (tc.field != "" && extensions["field"] != tc.field) {
+					t.Errorf("expected extensions: %v %v, got %v", CodeInvalidParameter, tc.field, extensions)
+				}
+			})
+		}
+	})
+}
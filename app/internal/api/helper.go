@@ -2,9 +2,14 @@ package api
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
 	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
 )
 
 func JSONResponse(w http.ResponseWriter, status int, result interface{}, errMsg, successMsg string) {
@@ -25,3 +30,41 @@ func JSONResponse(w http.ResponseWriter, status int, result interface{}, errMsg,
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
+
+// parseSnapshot parses the optional snapshot query param.
+func parseSnapshot(snapshotStr string) (bool, error) {
+	if snapshotStr == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(snapshotStr)
+}
+
+// applyAdjustments reports every param the pagination policy clamped in a
+// Warning header and rewrites the clamped query params of the request, so
+// the navigation links carry the applied values.
+func applyAdjustments(w http.ResponseWriter, r *http.Request, adjustments ...*pagination.Adjustment) {
+	query := r.URL.Query()
+	rewritten := false
+	for _, adjusted := range adjustments {
+		if adjusted == nil {
+			continue
+		}
+
+		w.Header().Add("Warning", fmt.Sprintf("299 - %q", adjusted.String()))
+		if query.Has(adjusted.Param) {
+			query.Set(adjusted.Param, strconv.Itoa(adjusted.Applied))
+			rewritten = true
+		}
+	}
+	if rewritten {
+		r.URL.RawQuery = query.Encode()
+	}
+}
+
+// QueryBudget bounds every statement run for the requests served by next
+// by budget, a non positive budget leaves them unbounded.
+func QueryBudget(next http.Handler, budget time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repo.WithQueryBudget(r.Context(), budget)))
+	})
+}
@@ -2,22 +2,28 @@ package api
 
 import (
 	"net/http"
-	"strconv"
+	"slices"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 )
 
 type LimitOffsetHttpControler struct {
 	Handler pagination.LimitOffSetHandler
+	Policy  pagination.Policy
 }
 
-func NewLimitOffsetHttpControler(repo pagination.LimitOffSetHandler) LimitOffsetHttpControler {
+func NewLimitOffsetHttpControler(repo pagination.LimitOffSetHandler, policy pagination.Policy) LimitOffsetHttpControler {
 	return LimitOffsetHttpControler{
 		Handler: repo,
+		Policy:  policy,
 	}
 }
 
+// limitOffsetParams are the query params GetUsers reads besides the filters.
+var limitOffsetParams = []string{"page", "limit", "sort", "mode", "count", "after", "snapshot", "session"}
+
 func (h LimitOffsetHttpControler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	// tracer span instance
 	tracerHander := pkg.TracerConfigHandler{}
@@ -26,29 +32,48 @@ func (h LimitOffsetHttpControler) GetUsers(w http.ResponseWriter, r *http.Reques
 
 	url := r.URL.Query()
 
-	pageStr := url.Get("page")
-	limitStr := url.Get("limit")
+	if err := h.Policy.CheckParams(url, slices.Concat(limitOffsetParams, pagination.FilterParams(repo.UserFilterColumns))); err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	limitInt, limitAdjusted, err := h.Policy.ParseLimit("limit", url.Get("limit"))
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
 
-	var d interface{}
-	pageInt, err := strconv.Atoi(pageStr)
+	pageInt, pageAdjusted, err := h.Policy.ParsePage(url.Get("page"), limitInt)
 	if err != nil {
-		JSONResponse(w, http.StatusBadRequest, d, "invalid page", "")
+		errorResponse(ctx, w, r, err)
 		return
 	}
+	applyAdjustments(w, r, limitAdjusted, pageAdjusted)
 
-	limitInt, err := strconv.Atoi(limitStr)
+	snapshot, err := parseSnapshot(url.Get("snapshot"))
 	if err != nil {
-		JSONResponse(w, http.StatusBadRequest, d, "invalid limit", "")
+		errorResponse(ctx, w, r, &pagination.ParamError{Param: "snapshot", Err: pagination.ErrInvalidSnapshot})
 		return
 	}
 
-	userData, err := h.Handler.RetrieveUsers(ctx, pageInt, limitInt)
+	userData, err := h.Handler.RetrieveUsers(ctx, pagination.OffsetRequest{
+		Page:     pageInt,
+		Limit:    limitInt,
+		Sort:     url.Get("sort"),
+		Filters:  pagination.ParseFilters(url, repo.UserFilterColumns),
+		Mode:     url.Get("mode"),
+		Count:    url.Get("count"),
+		After:    url.Get("after"),
+		Snapshot: snapshot,
+		Session:  url.Get("session"),
+	})
 	if err != nil {
-		span.RecordError(err)
-		JSONResponse(w, http.StatusInternalServerError, d, "something went wrong", "")
+		errorResponse(ctx, w, r, err)
 		return
 	}
 
+	userData.Links = limitOffsetLinks(r, userData)
+	setLinkHeader(w, userData.Links)
 	JSONResponse(w, http.StatusOK, userData, "", "retrieved successfully")
 
 }
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// limitOffsetLinks returns the navigation links of a limit-offset page. The
+// last page is only linked when the pages were counted and the next page
+// link of an adaptive page carries the cursor it continues from.
+func limitOffsetLinks(r *http.Request, data model.UsersPaginationMetaData) *model.PageLinks {
+	query := navigationQuery(r, data.Session)
+	pg := data.Pagination
+
+	links := model.PageLinks{
+		First: linkURL(r, query, map[string]string{"page": "1"}, "after"),
+	}
+	if pg.CurrentPage > 1 {
+		links.Prev = linkURL(r, query, map[string]string{"page": strconv.Itoa(pg.CurrentPage - 1)}, "after")
+	}
+	if pg.NextPage > pg.CurrentPage {
+		next := map[string]string{"page": strconv.Itoa(pg.NextPage)}
+		if pg.NextCursor != nil {
+			next["after"] = *pg.NextCursor
+		}
+		links.Next = linkURL(r, query, next, "after")
+	}
+	if pg.CountMode != pagination.CountNone && pg.TotalPages > 0 {
+		links.Last = linkURL(r, query, map[string]string{"page": strconv.Itoa(pg.TotalPages)}, "after")
+	}
+	return &links
+}
+
+// cursorLinks returns the navigation links of a cursor based page. No cursor
+// points at the last page so it is never linked.
+func cursorLinks(r *http.Request, data model.UsersCursorBasedMetaData) *model.PageLinks {
+	query := navigationQuery(r, data.Session)
+
+	links := model.PageLinks{
+		First: linkURL(r, query, nil, "after", "before", "cursor"),
+	}
+	if data.PrevCursor != nil {
+		links.Prev = linkURL(r, query, map[string]string{"before": *data.PrevCursor}, "after", "cursor")
+	}
+	if data.NextCursor != nil {
+		links.Next = linkURL(r, query, map[string]string{"after": *data.NextCursor}, "before", "cursor")
+	}
+	return &links
+}
+
+// navigationQuery returns the query params of the request every navigation
+// link keeps. A page read from a snapshot session links to the same session
+// instead of opening a new one.
+func navigationQuery(r *http.Request, session *model.SnapshotSession) url.Values {
+	query := r.URL.Query()
+	if session != nil {
+		query.Del("snapshot")
+		query.Set("session", session.Token)
+	}
+	return query
+}
+
+// linkURL returns the request path with a copy of query where the set params
+// are replaced and the dropped ones removed.
+func linkURL(r *http.Request, query url.Values, set map[string]string, drop ...string) string {
+	params := url.Values{}
+	for key, values := range query {
+		params[key] = values
+	}
+	for _, key := range drop {
+		params.Del(key)
+	}
+	for key, value := range set {
+		params.Set(key, value)
+	}
+
+	link := url.URL{Path: r.URL.Path, RawQuery: params.Encode()}
+	return link.String()
+}
+
+// setLinkHeader sets the RFC 8288 Link header of the page links.
+func setLinkHeader(w http.ResponseWriter, links *model.PageLinks) {
+	var values []string
+	for _, link := range []struct {
+		rel string
+		url string
+	}{
+		{rel: "first", url: links.First},
+		{rel: "prev", url: links.Prev},
+		{rel: "next", url: links.Next},
+		{rel: "last", url: links.Last},
+	} {
+		if link.url != "" {
+			values = append(values, fmt.Sprintf(`<%v>; rel="%v"`, link.url, link.rel))
+		}
+	}
+
+	if len(values) > 0 {
+		w.Header().Set("Link", strings.Join(values, ", "))
+	}
+}
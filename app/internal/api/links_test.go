@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+func TestPageLinks(t *testing.T) {
+	newRequest := func(t testing.TB, target string) *http.Request {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		return req
+	}
+
+	t.Run("limit offset", func(t *testing.T) {
+		req := newRequest(t, "/users/limit-offset?page=2&limit=10&name_prefix=Ja&sort=-name")
+		got := limitOffsetLinks(req, model.UsersPaginationMetaData{
+			Pagination: model.Pagination{CurrentPage: 2, NextPage: 3, PrevPage: 1, TotalPages: 5, CountMode: "exact"},
+		})
+
+		want := &model.PageLinks{
+			First: "/users/limit-offset?limit=10&name_prefix=Ja&page=1&sort=-name",
+			Prev:  "/users/limit-offset?limit=10&name_prefix=Ja&page=1&sort=-name",
+			Next:  "/users/limit-offset?limit=10&name_prefix=Ja&page=3&sort=-name",
+			Last:  "/users/limit-offset?limit=10&name_prefix=Ja&page=5&sort=-name",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected links: %v, got %v", want, got)
+		}
+	})
+
+	t.Run("limit offset last page without count", func(t *testing.T) {
+		req := newRequest(t, "/users/limit-offset?page=4&limit=10&count=none")
+		got := limitOffsetLinks(req, model.UsersPaginationMetaData{
+			Pagination: model.Pagination{CurrentPage: 4, NextPage: 4, PrevPage: 3, CountMode: "none"},
+		})
+
+		want := &model.PageLinks{
+			First: "/users/limit-offset?count=none&limit=10&page=1",
+			Prev:  "/users/limit-offset?count=none&limit=10&page=3",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected links: %v, got %v", want, got)
+		}
+	})
+
+	t.Run("cursor based", func(t *testing.T) {
+		next, prev := "next-token", "prev-token"
+		req := newRequest(t, "/users/cursor-based?cursor=current-token&limit=10&snapshot=true")
+		got := cursorLinks(req, model.UsersCursorBasedMetaData{
+			NextCursor: &next,
+			PrevCursor: &prev,
+			Session:    &model.SnapshotSession{Token: "abc"},
+		})
+
+		// pages after the first are read from the session the first one opened
+		want := &model.PageLinks{
+			First: "/users/cursor-based?limit=10&session=abc",
+			Prev:  "/users/cursor-based?before=prev-token&limit=10&session=abc",
+			Next:  "/users/cursor-based?after=next-token&limit=10&session=abc",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected links: %v, got %v", want, got)
+		}
+	})
+
+	t.Run("link header", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		setLinkHeader(resp, &model.PageLinks{First: "/users?page=1", Next: "/users?page=2"})
+
+		want := `</users?page=1>; rel="first", </users?page=2>; rel="next"`
+		if got := resp.Header().Get("Link"); got != want {
+			t.Errorf("expected header: %v, got %v", want, got)
+		}
+	})
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain"
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// problemMediaType is the media type of problem details responses.
+const problemMediaType = "application/problem+json"
+
+// problem codes, they are part of the API and never change meaning
+const (
+	CodeInvalidParameter    = "invalid_parameter"
+	CodeInvalidBody         = "invalid_body"
+	CodeNotFound            = "not_found"
+	CodeLimitOutOfRange     = "limit_out_of_range"
+	CodeOffsetOutOfRange    = "offset_out_of_range"
+	CodeCursorExpired       = "cursor_expired"
+	CodeQueryTimeout        = "query_timeout"
+	CodeDBUnavailable       = "db_unavailable"
+	CodeRangeNotSatisfiable = "range_not_satisfiable"
+	CodeNotAcceptable       = "not_acceptable"
+	CodeInternal            = "internal_error"
+)
+
+// problemKind is the status, code and title of the problems of an error,
+// field is the param at fault when the error doesn't name one.
+type problemKind struct {
+	err    error
+	status int
+	code   string
+	title  string
+	field  string
+}
+
+// problemKinds maps the domain and repository errors to their problems,
+// the first kind whose error matches wins.
+var problemKinds = []problemKind{
+	{err: pagination.ErrLimitOutOfRange, status: http.StatusBadRequest, code: CodeLimitOutOfRange, title: "Limit out of range", field: "limit"},
+	{err: pagination.ErrOffsetOutOfRange, status: http.StatusBadRequest, code: CodeOffsetOutOfRange, title: "Offset out of range", field: "page"},
+	{err: pagination.ErrUnknownParam, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Unknown parameter"},
+	{err: pagination.ErrInvalidLimit, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "limit"},
+	{err: pagination.ErrInvalidPosition, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "page"},
+	{err: pagination.ErrInvalidRange, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "Range"},
+	{err: pagination.ErrInvalidSnapshot, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "snapshot"},
+	{err: pagination.ErrInvalidCursor, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "after"},
+	{err: pagination.ErrInvalidSort, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "sort"},
+	{err: pagination.ErrInvalidFilter, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter"},
+	{err: pagination.ErrInvalidMode, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "mode"},
+	{err: pagination.ErrInvalidCountMode, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "count"},
+	{err: pagination.ErrUnknownStrategy, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "strategy"},
+	{err: errConnectionDirection, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter"},
+	{err: errConnectionSize, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter"},
+	{err: pagination.ErrRangeNotSatisfiable, status: http.StatusRequestedRangeNotSatisfiable, code: CodeRangeNotSatisfiable, title: "Range not satisfiable", field: "Range"},
+	{err: errInvalidUserID, status: http.StatusBadRequest, code: CodeInvalidParameter, title: "Invalid parameter", field: "id"},
+	{err: errInvalidBody, status: http.StatusBadRequest, code: CodeInvalidBody, title: "Invalid body"},
+	{err: domain.ErrInvalidUser, status: http.StatusBadRequest, code: CodeInvalidBody, title: "Invalid user"},
+	{err: domain.ErrInvalidUserBatch, status: http.StatusBadRequest, code: CodeInvalidBody, title: "Invalid user batch"},
+	{err: repo.ErrUserNotFound, status: http.StatusNotFound, code: CodeNotFound, title: "User not found", field: "id"},
+	{err: errNotAcceptable, status: http.StatusNotAcceptable, code: CodeNotAcceptable, title: "Not acceptable", field: "Accept"},
+	{err: repo.ErrSessionNotFound, status: http.StatusGone, code: CodeCursorExpired, title: "Snapshot session expired", field: "session"},
+	{err: repo.ErrTooManySessions, status: http.StatusServiceUnavailable, code: CodeDBUnavailable, title: "Too many snapshot sessions", field: "snapshot"},
+	{err: repo.ErrQueryTimeout, status: http.StatusGatewayTimeout, code: CodeQueryTimeout, title: "Query timeout"},
+	{err: repo.ErrDBUnavailable, status: http.StatusServiceUnavailable, code: CodeDBUnavailable, title: "Database unavailable"},
+}
+
+// errNotAcceptable is returned when no representation matches the Accept header.
+var errNotAcceptable = errors.New("not acceptable")
+
+// internalProblem is the kind of the errors missing from problemKinds.
+var internalProblem = problemKind{
+	err:    errors.New("something went wrong"),
+	status: http.StatusInternalServerError,
+	code:   CodeInternal,
+	title:  "Internal error",
+}
+
+// problemOf returns the problem details of err, the kind it matched and
+// whether err is one of the known errors.
+func problemOf(err error) (model.Problem, problemKind, bool) {
+	kind, known := internalProblem, false
+	for _, k := range problemKinds {
+		if errors.Is(err, k.err) {
+			kind, known = k, true
+			break
+		}
+	}
+
+	problem := model.Problem{
+		Type:   "/problems/" + kind.code,
+		Title:  kind.title,
+		Status: kind.status,
+		Detail: kind.err.Error(),
+		Code:   kind.code,
+		Field:  kind.field,
+	}
+	var paramErr *pagination.ParamError
+	if known && errors.As(err, &paramErr) {
+		problem.Field, problem.Detail = paramErr.Param, paramErr.Error()
+	}
+	var userErr *domain.UserError
+	if known && errors.As(err, &userErr) {
+		problem.Field, problem.Detail = userErr.Field, userErr.Error()
+	}
+	return problem, kind, known
+}
+
+// errorResponse writes the problem details of err. Errors of the request
+// params name the param at fault, unknown errors are logged and answered
+// with an internal error that doesn't leak them. Nothing is written for
+// requests whose client went away.
+func errorResponse(ctx context.Context, w http.ResponseWriter, r *http.Request, err error) {
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+
+	problem, _, known := problemOf(err)
+	if !known {
+		log.Printf("%v failed with error: %v", r.URL.Path, err)
+	}
+	problem.Instance = r.URL.RequestURI()
+	if traceID := span.SpanContext().TraceID(); traceID.IsValid() {
+		problem.TraceID = traceID.String()
+	}
+
+	w.Header().Set("Content-Type", problemMediaType)
+	w.WriteHeader(problem.Status)
+	if err := json.NewEncoder(w).Encode(problem); err != nil {
+		log.Printf("problem response encoding failed with error: %v", err)
+	}
+}
+
+// problemError is the GraphQL error of a problem, its message is the one of
+// the domain error and its extensions carry the problem code and field.
+type problemError struct {
+	message string
+	problem model.Problem
+}
+
+func (e problemError) Error() string {
+	return e.message
+}
+
+// Extensions implements gqlerrors.ExtendedError.
+func (e problemError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.problem.Code}
+	if e.problem.Field != "" {
+		extensions["field"] = e.problem.Field
+	}
+	return extensions
+}
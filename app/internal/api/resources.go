@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// ResourceHttpController serves the listing of a manifest resource with
+// every pagination strategy, the same way GET /users does.
+type ResourceHttpController struct {
+	Name     string
+	Registry *pagination.Registry[model.Record]
+}
+
+func NewResourceHttpController(name string, registry *pagination.Registry[model.Record]) ResourceHttpController {
+	return ResourceHttpController{
+		Name:     name,
+		Registry: registry,
+	}
+}
+
+// GetItems dispatches the request to its strategy, see servePage.
+func (h ResourceHttpController) GetItems(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "resource-httpController", "controller: get-"+h.Name)
+	defer span.End()
+
+	servePage(ctx, w, r, h.Registry, func(strategy string, page pagination.Page[model.Record], links *model.PageLinks) interface{} {
+		return model.ResourcePage{
+			Resource: h.Name,
+			Strategy: strategy,
+			ServedBy: page.ServedBy,
+			Items:    page.Items,
+			Total:    page.Total,
+			Session:  page.Session,
+			Links:    links,
+		}
+	})
+}
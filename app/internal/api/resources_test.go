@@ -0,0 +1,149 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestResourceItems(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	registry, err := pagination.NewManifestRegistry(db, pagination.ManifestResource{
+		Name:            "orders",
+		Table:           "orders",
+		Key:             "id",
+		Fields:          []string{"id", "status", "total"},
+		Sort:            []string{"status"},
+		Filters:         []string{"status", "total"},
+		MaxPageSize:     2,
+		DefaultStrategy: pagination.StrategyKeyset,
+		FilterTypes:     map[string]string{"status": "text", "total": "numeric"},
+	}, pagination.PaginatorConfig{CursorSecret: "secret"})
+	if err != nil {
+		t.Fatalf("registry creation failed with error: %v", err)
+	}
+	httpController := NewResourceHttpController("orders", registry)
+
+	t.Run("default strategy with a capped page size", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, status, total FROM orders WHERE status = $1 ORDER BY id DESC LIMIT $2;").
+			WithArgs("paid", 3).
+			WillReturnRows(mock.NewRows([]string{"id", "status", "total"}).
+				AddRow(9, "paid", []byte("12.50")).AddRow(7, "paid", []byte("3.10")).AddRow(4, "paid", []byte("8.00")))
+
+		req, err := http.NewRequest(http.MethodGet, "/resources/orders?status=paid&limit=50", nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		httpController.GetItems(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		body := resp.Body.String()
+		for _, want := range []string{
+			`"Resource":"orders"`,
+			`"Strategy":"keyset"`,
+			`"Items":[{"id":9,"status":"paid","total":"12.50"},{"id":7,"status":"paid","total":"3.10"}]`,
+		} {
+			if !strings.Contains(body, want) {
+				t.Errorf("expected body to hold %v, got %v", want, body)
+			}
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("cursor of another resource", func(t *testing.T) {
+		// the users cursor matches the sort and key count of orders
+		usersCursor, err := pagination.NewCursorCodec("secret").Encode(model.Cursor{
+			Resource:  "users",
+			Direction: model.SortDesc,
+			Sort:      "-id",
+			Keys:      []string{"5"},
+		})
+		if err != nil {
+			t.Fatalf("cursor encoding failed with error: %v", err)
+		}
+
+		req, err := http.NewRequest(http.MethodGet, "/resources/orders?strategy=keyset&after="+usersCursor, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		httpController.GetItems(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("expected code: %v, got %v", http.StatusBadRequest, resp.Code)
+		}
+		if body := resp.Body.String(); !strings.Contains(body, `"code":"invalid_parameter"`) || !strings.Contains(body, `"field":"after"`) {
+			t.Errorf("expected an invalid after problem, got %v", body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("prefix filter of a text column", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, status, total FROM orders WHERE status LIKE $1 ORDER BY id DESC LIMIT $2;").
+			WithArgs("pa%", 3).
+			WillReturnRows(mock.NewRows([]string{"id", "status", "total"}).AddRow(9, "paid", []byte("12.50")))
+
+		req, err := http.NewRequest(http.MethodGet, "/resources/orders?status_prefix=pa", nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		httpController.GetItems(resp, req)
+
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("prefix filter of a numeric column", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/resources/orders?total_prefix=12", nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		httpController.GetItems(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("expected code: %v, got %v", http.StatusBadRequest, resp.Code)
+		}
+		if body := resp.Body.String(); !strings.Contains(body, `"code":"invalid_parameter"`) || !strings.Contains(body, `"field":"total"`) {
+			t.Errorf("expected an invalid total problem, got %v", body)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("undeclared sort field", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, "/resources/orders?sort=total", nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		httpController.GetItems(resp, req)
+
+		if resp.Code != http.StatusBadRequest {
+			t.Errorf("expected code: %v, got %v", http.StatusBadRequest, resp.Code)
+		}
+	})
+}
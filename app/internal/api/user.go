@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// maxUserBodyBytes bounds the body of user writes, enough for a batch of
+// domain.MaxCreateUsers users of the widest names.
+const maxUserBodyBytes = 2 << 20
+
+var (
+	// errInvalidUserID is returned when the id path value isn't a user id.
+	errInvalidUserID = errors.New("invalid user id")
+	// errInvalidBody is returned for request bodies that can't be decoded.
+	errInvalidBody = errors.New("invalid body")
+)
+
+type usersHandlerInterface interface {
+	Create(ctx context.Context, users []model.UserGenData) ([]model.User, error)
+	Get(ctx context.Context, id int) (model.User, error)
+	Update(ctx context.Context, id int, patch model.UserPatch) (model.User, error)
+	Delete(ctx context.Context, id int) error
+}
+
+// UserHttpController serves the writes and single reads of users under
+// POST /users and GET, PATCH and DELETE /users/{id}.
+type UserHttpController struct {
+	Handler usersHandlerInterface
+}
+
+func NewUserHttpController(handler usersHandlerInterface) UserHttpController {
+	return UserHttpController{
+		Handler: handler,
+	}
+}
+
+// CreateUsers stores the JSON array of users of the body and answers with
+// them as stored.
+func (h UserHttpController) CreateUsers(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "user-httpController", "controller: create-users")
+	defer span.End()
+
+	var users []model.UserGenData
+	if err := decodeBody(w, r, &users); err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	created, err := h.Handler.Create(ctx, users)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+	JSONResponse(w, http.StatusCreated, created, "", "created successfully")
+}
+
+// GetUser answers with the user of the id path value.
+func (h UserHttpController) GetUser(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "user-httpController", "controller: get-user")
+	defer span.End()
+
+	id, err := userID(r)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	user, err := h.Handler.Get(ctx, id)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+	JSONResponse(w, http.StatusOK, user, "", "retrieved successfully")
+}
+
+// PatchUser sets the fields of the JSON body on the user of the id path
+// value and answers with the updated user.
+func (h UserHttpController) PatchUser(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "user-httpController", "controller: patch-user")
+	defer span.End()
+
+	id, err := userID(r)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	var patch model.UserPatch
+	if err := decodeBody(w, r, &patch); err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	user, err := h.Handler.Update(ctx, id, patch)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+	JSONResponse(w, http.StatusOK, user, "", "updated successfully")
+}
+
+// DeleteUser deletes the user of the id path value.
+func (h UserHttpController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "user-httpController", "controller: delete-user")
+	defer span.End()
+
+	id, err := userID(r)
+	if err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	if err := h.Handler.Delete(ctx, id); err != nil {
+		errorResponse(ctx, w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// userID parses the id path value of the request.
+func userID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil || id < 1 {
+		return 0, &pagination.ParamError{Param: "id", Err: errInvalidUserID}
+	}
+	return id, nil
+}
+
+// decodeBody decodes the JSON body of the request into v, rejecting unknown
+// fields and bodies larger than maxUserBodyBytes.
+func decodeBody(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxUserBodyBytes))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidBody, err)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/domain"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+func TestUserCRUD(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	httpController := NewUserHttpController(domain.NewUsersHandler(repo.RepositoryHandler{Db: db}))
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users", httpController.CreateUsers)
+	mux.HandleFunc("GET /users/{id}", httpController.GetUser)
+	mux.HandleFunc("PATCH /users/{id}", httpController.PatchUser)
+	mux.HandleFunc("DELETE /users/{id}", httpController.DeleteUser)
+
+	columns := []string{"id", "name", "surname", "created_at", "updated_at"}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	selectUser := "SELECT id, name, surname, created_at, updated_at FROM users WHERE id = $1;"
+	updateUser := "UPDATE users SET name = COALESCE($2, name), surname = COALESCE($3, surname), updated_at = now() WHERE id = $1 RETURNING id, name, surname, created_at, updated_at;"
+
+	request := func(t testing.TB, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req, err := http.NewRequest(method, target, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		mux.ServeHTTP(resp, req)
+		return resp
+	}
+
+	decode := func(t testing.TB, resp *httptest.ResponseRecorder, data any) model.Problem {
+		t.Helper()
+		payload := struct {
+			model.Problem
+			Data any
+		}{Data: data}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		return payload.Problem
+	}
+
+	assertProblem := func(t testing.TB, resp *httptest.ResponseRecorder, status int, code, field string) {
+		t.Helper()
+		if resp.Code != status {
+			t.Errorf("expected code: %v, got %v", status, resp.Code)
+		}
+		problem := decode(t, resp, nil)
+		if problem.Code != code || problem.Field != field {
+			t.Errorf("expected problem %v of field %q, got %+v", code, field, problem)
+		}
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("create users", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT nextval(pg_get_serial_sequence('users', 'id')) FROM generate_series(1, $1);").
+			WithArgs(1).
+			WillReturnRows(mock.NewRows([]string{"nextval"}).AddRow(7))
+		copyStmt := mock.ExpectPrepare(pq.CopyIn("users", "id", "name", "surname"))
+		copyStmt.ExpectExec().WithArgs(7, "Jane", "Doe").WillReturnResult(sqlmock.NewResult(0, 1))
+		copyStmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT id, name, surname, created_at, updated_at FROM users WHERE id = ANY($1) ORDER BY array_position($1, id);").
+			WithArgs(pq.Array([]int{7})).
+			WillReturnRows(mock.NewRows(columns).AddRow(7, "Jane", "Doe", created, created))
+		mock.ExpectCommit()
+
+		resp := request(t, http.MethodPost, "/users", `[{"Name": "Jane", "Surname": "Doe"}]`)
+		if resp.Code != http.StatusCreated {
+			t.Errorf("expected code: %v, got %v", http.StatusCreated, resp.Code)
+		}
+		var users []model.User
+		decode(t, resp, &users)
+		if len(users) != 1 || users[0].ID != 7 || !users[0].CreatedAt.Equal(created) {
+			t.Errorf("expected user 7 created at %v, got %+v", created, users)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("create users rejects", func(t *testing.T) {
+		testCases := []struct {
+			name  string
+			body  string
+			field string
+		}{
+			{name: "malformed body", body: `{"Name": "Jane"}`},
+			{name: "unknown field", body: `[{"Name": "Jane", "Age": 30}]`},
+			{name: "empty batch", body: `[]`},
+			{name: "empty name", body: `[{"Name": "", "Surname": "Doe"}]`, field: "Name"},
+			{name: "long surname", body: `[{"Name": "Jane", "Surname": "` + strings.Repeat("x", 201) + `"}]`, field: "Surname"},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				resp := request(t, http.MethodPost, "/users", tc.body)
+				assertProblem(t, resp, http.StatusBadRequest, CodeInvalidBody, tc.field)
+				assertExpectations(t)
+			})
+		}
+	})
+
+	t.Run("get user", func(t *testing.T) {
+		mock.ExpectQuery(selectUser).WithArgs(7).
+			WillReturnRows(mock.NewRows(columns).AddRow(7, "Jane", "Doe", created, created))
+
+		resp := request(t, http.MethodGet, "/users/7", "")
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		var user model.User
+		decode(t, resp, &user)
+		if user.ID != 7 || user.Name != "Jane" {
+			t.Errorf("expected user 7, got %+v", user)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("get user not found", func(t *testing.T) {
+		mock.ExpectQuery(selectUser).WithArgs(99).WillReturnRows(mock.NewRows(columns))
+
+		resp := request(t, http.MethodGet, "/users/99", "")
+		assertProblem(t, resp, http.StatusNotFound, CodeNotFound, "id")
+		assertExpectations(t)
+	})
+
+	t.Run("invalid user id", func(t *testing.T) {
+		for _, target := range []string{"/users/abc", "/users/0"} {
+			resp := request(t, http.MethodGet, target, "")
+			assertProblem(t, resp, http.StatusBadRequest, CodeInvalidParameter, "id")
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("patch user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateUser).WithArgs(7, "Janet", nil).
+			WillReturnRows(mock.NewRows(columns).AddRow(7, "Janet", "Doe", created, created.Add(time.Hour)))
+		mock.ExpectCommit()
+
+		resp := request(t, http.MethodPatch, "/users/7", `{"Name": "Janet"}`)
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		var user model.User
+		decode(t, resp, &user)
+		if user.Name != "Janet" || !user.UpdatedAt.After(user.CreatedAt) {
+			t.Errorf("expected the updated user, got %+v", user)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("patch user rejects an empty name", func(t *testing.T) {
+		resp := request(t, http.MethodPatch, "/users/7", `{"Name": ""}`)
+		assertProblem(t, resp, http.StatusBadRequest, CodeInvalidBody, "Name")
+		assertExpectations(t)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = $1;").WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		resp := request(t, http.MethodDelete, "/users/7", "")
+		if resp.Code != http.StatusNoContent {
+			t.Errorf("expected code: %v, got %v", http.StatusNoContent, resp.Code)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("delete user not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = $1;").WithArgs(99).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		resp := request(t, http.MethodDelete, "/users/99", "")
+		assertProblem(t, resp, http.StatusNotFound, CodeNotFound, "id")
+		assertExpectations(t)
+	})
+}
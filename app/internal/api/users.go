@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// UsersHttpController serves GET /users with every registered pagination
+// strategy, picked by the strategy query param.
+type UsersHttpController struct {
+	Registry *pagination.Registry[model.UserData]
+}
+
+func NewUsersHttpController(registry *pagination.Registry[model.UserData]) UsersHttpController {
+	return UsersHttpController{
+		Registry: registry,
+	}
+}
+
+// GetUsers dispatches the request to its strategy, see servePage.
+func (h UsersHttpController) GetUsers(w http.ResponseWriter, r *http.Request) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(r.Context(), "users-httpController", "controller: get-users")
+	defer span.End()
+
+	servePage(ctx, w, r, h.Registry, func(strategy string, page pagination.Page[model.UserData], links *model.PageLinks) interface{} {
+		return model.UsersPage{
+			Strategy: strategy,
+			ServedBy: page.ServedBy,
+			IndexAge: page.IndexAge,
+			Users:    page.Items,
+			Total:    page.Total,
+			Session:  page.Session,
+			Links:    links,
+		}
+	})
+}
+
+// servePage reads the page of a request with its strategy and writes the
+// page payload built by respond. A request without a strategy param uses
+// the range strategy when it sends a Range header and the registry fallback
+// otherwise. Pages of strategies that know the position of their first item
+// carry a Content-Range header, answered with 206 Partial Content when the
+// request asked for a range.
+func servePage[T any](ctx context.Context, w http.ResponseWriter, r *http.Request, registry *pagination.Registry[T], respond func(strategy string, page pagination.Page[T], links *model.PageLinks) interface{}) {
+	w.Header().Set("Accept-Ranges", "items")
+
+	url := r.URL.Query()
+	name := url.Get("strategy")
+	if name == "" && r.Header.Get("Range") != "" {
+		name = pagination.StrategyRange
+	}
+
+	strategy, err := registry.Lookup(name)
+	if err != nil {
+		errorResponse(ctx, w, r, &pagination.ParamError{Param: "strategy", Err: err})
+		return
+	}
+
+	var page pagination.Page[T]
+	req, err := strategy.Parse(url, r.Header)
+	if err == nil {
+		applyAdjustments(w, r, req.Adjustments...)
+		page, err = strategy.Paginate(ctx, req)
+	}
+	if err != nil {
+		if errors.Is(err, pagination.ErrRangeNotSatisfiable) {
+			w.Header().Set("Content-Range", fmt.Sprintf("items */%v", rangeTotal(page.Total)))
+		}
+		errorResponse(ctx, w, r, err)
+		return
+	}
+
+	status, links := pageHeaders(w, r, strategy, page)
+	JSONResponse(w, status, respond(strategy.Name, page, links), "", "retrieved successfully")
+}
+
+// pageHeaders sets the Link and Content-Range headers of a page read with
+// the strategy and returns its status together with its links.
+func pageHeaders[T any](w http.ResponseWriter, r *http.Request, strategy pagination.Strategy[T], page pagination.Page[T]) (int, *model.PageLinks) {
+	query := navigationQuery(r, page.Session)
+	query.Set("strategy", strategy.Name)
+
+	links := &model.PageLinks{
+		First: linkURL(r, query, nil, strategy.PositionParams...),
+	}
+	if page.Prev != nil {
+		links.Prev = linkURL(r, query, page.Prev, strategy.PositionParams...)
+	}
+	if page.Next != nil {
+		links.Next = linkURL(r, query, page.Next, strategy.PositionParams...)
+	}
+	if page.Last != nil {
+		links.Last = linkURL(r, query, page.Last, strategy.PositionParams...)
+	}
+	setLinkHeader(w, links)
+
+	status := http.StatusOK
+	if page.Offset != nil {
+		if len(page.Items) == 0 {
+			w.Header().Set("Content-Range", fmt.Sprintf("items */%v", rangeTotal(page.Total)))
+		} else {
+			last := *page.Offset + len(page.Items) - 1
+			w.Header().Set("Content-Range", fmt.Sprintf("items %v-%v/%v", *page.Offset, last, rangeTotal(page.Total)))
+			if r.Header.Get("Range") != "" {
+				status = http.StatusPartialContent
+			}
+		}
+	}
+	return status, links
+}
+
+// rangeTotal renders the complete length of a Content-Range, "*" when unknown.
+func rangeTotal(total *int) string {
+	if total == nil {
+		return "*"
+	}
+	return strconv.Itoa(*total)
+}
@@ -0,0 +1,501 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+func TestUsersStrategies(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	registry := pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{CursorSecret: "secret"}))
+	httpController := NewUsersHttpController(registry)
+
+	withTotal := []string{"id", "name", "surname", "count"}
+	request := func(t testing.TB, target, rangeHeader string) (*httptest.ResponseRecorder, model.UsersPage, model.Problem) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+
+		resp := httptest.NewRecorder()
+		httpController.GetUsers(resp, req)
+
+		var payload struct {
+			model.Problem
+			Data model.UsersPage
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		return resp, payload.Data, payload.Problem
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	assertHeader := func(t testing.TB, resp *httptest.ResponseRecorder, key, want string) {
+		t.Helper()
+		if got := resp.Header().Get(key); got != want {
+			t.Errorf("expected %v header: %v, got %v", key, want, got)
+		}
+	}
+
+	t.Run("fallback offset strategy", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+			WithArgs(2, 2).
+			WillReturnRows(mock.NewRows(withTotal).AddRow(3, "Jane", "Doe", 5).AddRow(4, "John", "Doe", 5))
+
+		resp, page, _ := request(t, "/users?page=2&limit=2", "")
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		if page.Strategy != pagination.StrategyOffset || len(page.Users) != 2 {
+			t.Errorf("expected 2 users of the offset strategy, got %+v", page)
+		}
+
+		want := model.PageLinks{
+			First: "/users?limit=2&strategy=offset",
+			Prev:  "/users?limit=2&page=1&strategy=offset",
+			Next:  "/users?limit=2&page=3&strategy=offset",
+			Last:  "/users?limit=2&page=3&strategy=offset",
+		}
+		if page.Links == nil || *page.Links != want {
+			t.Errorf("expected links: %+v, got %+v", want, page.Links)
+		}
+		assertHeader(t, resp, "Content-Range", "items 2-3/*")
+		assertExpectations(t)
+	})
+
+	t.Run("keyset strategy", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname FROM users ORDER BY id DESC LIMIT $1;").
+			WithArgs(3).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).AddRow(5, "Jane", "Doe").AddRow(4, "John", "Doe").AddRow(3, "Jim", "Doe"))
+
+		resp, page, _ := request(t, "/users?strategy=keyset&limit=2", "")
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		if page.Links == nil || page.Links.Next == "" || page.Links.Prev != "" || page.Links.Last != "" {
+			t.Errorf("expected a next link only, got %+v", page.Links)
+		}
+		assertHeader(t, resp, "Content-Range", "")
+		assertExpectations(t)
+	})
+
+	t.Run("range strategy", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+			WithArgs(2, 3).
+			WillReturnRows(mock.NewRows(withTotal).AddRow(4, "John", "Doe", 5).AddRow(5, "Jim", "Doe", 5))
+
+		resp, page, _ := request(t, "/users", "items=3-4")
+		if resp.Code != http.StatusPartialContent {
+			t.Errorf("expected code: %v, got %v", http.StatusPartialContent, resp.Code)
+		}
+		if page.Total == nil || *page.Total != 5 {
+			t.Errorf("expected total: %v, got %v", 5, page.Total)
+		}
+		assertHeader(t, resp, "Content-Range", "items 3-4/5")
+		assertExpectations(t)
+	})
+
+	t.Run("unsatisfiable range", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+			WithArgs(5, 10).
+			WillReturnRows(mock.NewRows(withTotal))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(5))
+
+		resp, _, problem := request(t, "/users", "items=10-14")
+		if resp.Code != http.StatusRequestedRangeNotSatisfiable {
+			t.Errorf("expected code: %v, got %v", http.StatusRequestedRangeNotSatisfiable, resp.Code)
+		}
+		if problem.Code != CodeRangeNotSatisfiable || problem.Status != resp.Code {
+			t.Errorf("expected problem code: %v, got %+v", CodeRangeNotSatisfiable, problem)
+		}
+		assertHeader(t, resp, "Content-Type", problemMediaType)
+		assertHeader(t, resp, "Content-Range", "items */5")
+		assertExpectations(t)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		testCases := []struct {
+			target string
+			header string
+			code   string
+			field  string
+		}{
+			{target: "/users?strategy=seek", code: CodeInvalidParameter, field: "strategy"},
+			{target: "/users?page=two", code: CodeInvalidParameter, field: "page"},
+			{target: "/users?limit=ten", code: CodeInvalidParameter, field: "limit"},
+			{target: "/users", header: "items=4-1", code: CodeInvalidParameter, field: "Range"},
+			{target: "/users?strategy=keyset&after=tampered", code: CodeInvalidParameter, field: "after"},
+			{target: "/users?strategy=keyset&before=tampered", code: CodeInvalidParameter, field: "before"},
+		}
+
+		for _, tc := range testCases {
+			resp, _, problem := request(t, tc.target, tc.header)
+			if resp.Code != http.StatusBadRequest || problem.Code != tc.code || problem.Field != tc.field {
+				t.Errorf("%v: expected %v %v %v, got %v %v %v", tc.target, http.StatusBadRequest, tc.code, tc.field, resp.Code, problem.Code, problem.Field)
+			}
+			if problem.Instance != tc.target || problem.Type != "/problems/"+tc.code {
+				t.Errorf("%v: expected instance and type of the request, got %+v", tc.target, problem)
+			}
+		}
+	})
+}
+
+func TestUsersAdaptiveOffset(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	registry := pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{
+		Mode:              pagination.OffsetModeAdaptive,
+		CursorSecret:      "secret",
+		AdaptiveThreshold: 4,
+	}))
+	httpController := NewUsersHttpController(registry)
+
+	request := func(t testing.TB, target string) (*httptest.ResponseRecorder, model.UsersPage, model.Problem) {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		httpController.GetUsers(resp, req)
+
+		var payload struct {
+			model.Problem
+			Data model.UsersPage
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		return resp, payload.Data, payload.Problem
+	}
+
+	assertPage := func(t testing.TB, resp *httptest.ResponseRecorder, page model.UsersPage, servedBy string) {
+		t.Helper()
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+		if page.ServedBy != servedBy {
+			t.Errorf("expected page served by: %v, got %v", servedBy, page.ServedBy)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	withTotal := []string{"id", "name", "surname", "count"}
+	var nextLink string
+
+	t.Run("shallow page reads the offset", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+			WithArgs(2, 2).
+			WillReturnRows(mock.NewRows(withTotal).AddRow(3, "Jane", "Doe", 7).AddRow(4, "John", "Doe", 7))
+
+		resp, page, _ := request(t, "/users?page=2&limit=2")
+		assertPage(t, resp, page, pagination.ServedByOffset)
+		if page.Links == nil || !strings.Contains(page.Links.Next, "after=") {
+			t.Fatalf("expected the next link to carry a cursor, got %+v", page.Links)
+		}
+		nextLink = page.Links.Next
+	})
+
+	t.Run("deep page continues from the cursor", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname FROM users WHERE id > $1 ORDER BY id ASC LIMIT $2;").
+			WithArgs("4", 3).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).AddRow(5, "Jim", "Doe").AddRow(6, "Jill", "Doe"))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(7))
+
+		resp, page, _ := request(t, nextLink)
+		assertPage(t, resp, page, pagination.ServedByKeyset)
+		if len(page.Users) != 2 || page.Users[0].ID != 5 {
+			t.Errorf("expected users 5 and 6, got %+v", page.Users)
+		}
+		if page.Links == nil || !strings.Contains(page.Links.Next, "page=4") || !strings.Contains(page.Links.Next, "after=") {
+			t.Errorf("expected the next link to page 4 with a cursor, got %+v", page.Links)
+		}
+		if strings.Contains(page.Links.Prev, "after=") {
+			t.Errorf("expected the prev link without a cursor, got %v", page.Links.Prev)
+		}
+	})
+
+	t.Run("jumped deep page looks up the previous row", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id FROM users ORDER BY id LIMIT 1 OFFSET $1;").
+			WithArgs(3).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow("4"))
+		mock.ExpectQuery("SELECT id, name, surname FROM users WHERE id > $1 ORDER BY id ASC LIMIT $2;").
+			WithArgs("4", 3).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).AddRow(5, "Jim", "Doe").AddRow(6, "Jill", "Doe"))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(7))
+
+		resp, page, _ := request(t, "/users?page=3&limit=2")
+		assertPage(t, resp, page, pagination.ServedByKeyset)
+	})
+
+	t.Run("tampered cursor", func(t *testing.T) {
+		resp, _, problem := request(t, "/users?page=3&limit=2&after=tampered")
+		if resp.Code != http.StatusBadRequest || problem.Code != CodeInvalidParameter || problem.Field != "after" {
+			t.Errorf("expected %v %v after, got %v %+v", http.StatusBadRequest, CodeInvalidParameter, resp.Code, problem)
+		}
+	})
+}
+
+func TestUsersPageIndex(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	index := repo.NewPageIndex(db, "users", "id", 100)
+	mock.ExpectQuery("SELECT id FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY id) - 1 AS position FROM users) boundaries WHERE position % $1 = 0 ORDER BY id;").
+		WithArgs(100).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1).AddRow(104).AddRow(230))
+	if err := index.Build(context.Background()); err != nil {
+		t.Fatalf("page index build failed with error: %v", err)
+	}
+
+	registry := pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{Boundaries: index}))
+	httpController := NewUsersHttpController(registry)
+
+	request := func(t testing.TB, target string) model.UsersPage {
+		t.Helper()
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+
+		resp := httptest.NewRecorder()
+		httpController.GetUsers(resp, req)
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+
+		var payload struct {
+			Data model.UsersPage
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+		return payload.Data
+	}
+
+	t.Run("deep page seeks a boundary", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname FROM users WHERE id >= $1 ORDER BY id LIMIT $2 OFFSET $3;").
+			WithArgs("230", 10, 50).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).AddRow(280, "Jane", "Doe"))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(400))
+
+		page := request(t, "/users?page=26&limit=10")
+		if page.ServedBy != pagination.ServedByIndex || page.IndexAge == "" {
+			t.Errorf("expected a page served by the index with its age, got %+v", page)
+		}
+		if page.Links == nil || page.Links.Last != "/users?limit=10&page=40&strategy=offset" {
+			t.Errorf("expected the last page counted from the total, got %+v", page.Links)
+		}
+	})
+
+	t.Run("adaptive jump past the threshold seeks a boundary", func(t *testing.T) {
+		adaptive := NewUsersHttpController(pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{
+			Mode:              pagination.OffsetModeAdaptive,
+			CursorSecret:      "secret",
+			AdaptiveThreshold: 100,
+			Boundaries:        index,
+		})))
+
+		mock.ExpectQuery("SELECT id FROM users WHERE id >= $1 ORDER BY id LIMIT 1 OFFSET $2;").
+			WithArgs("230", 49).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow("279"))
+		mock.ExpectQuery("SELECT id, name, surname FROM users WHERE id > $1 ORDER BY id ASC LIMIT $2;").
+			WithArgs("279", 11).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}).AddRow(280, "Jane", "Doe"))
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(400))
+
+		req, err := http.NewRequest(http.MethodGet, "/users?page=26&limit=10", nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		resp := httptest.NewRecorder()
+		adaptive.GetUsers(resp, req)
+
+		var payload struct {
+			Data model.UsersPage
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		if resp.Code != http.StatusOK || payload.Data.ServedBy != pagination.ServedByIndex || len(payload.Data.Users) != 1 {
+			t.Errorf("expected user 280 found through the index, got %v %+v", resp.Code, payload.Data)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("filtered page reads the offset", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users WHERE name = $1 ORDER BY id LIMIT $2 OFFSET $3;").
+			WithArgs("Jane", 10, 250).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname", "count"}).AddRow(251, "Jane", "Doe", 251))
+
+		page := request(t, "/users?page=26&limit=10&name=Jane")
+		if page.ServedBy != "" || page.IndexAge != "" {
+			t.Errorf("expected a plain offset page, got %+v", page)
+		}
+	})
+}
+
+func TestUsersQueryBudget(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	registry := pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{}))
+	httpController := NewUsersHttpController(registry)
+	handler := QueryBudget(http.HandlerFunc(httpController.GetUsers), 250*time.Millisecond)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = 250;").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+		WithArgs(10, 990000).
+		WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+	mock.ExpectRollback()
+
+	req, err := http.NewRequest(http.MethodGet, "/users?page=99001&limit=10", nil)
+	if err != nil {
+		t.Fatalf("request creation failed with error: %v", err)
+	}
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+
+	var problem model.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("Error decoding JSON: %v", err)
+	}
+	if resp.Code != http.StatusGatewayTimeout || problem.Code != CodeQueryTimeout {
+		t.Errorf("expected %v %v, got %v %+v", http.StatusGatewayTimeout, CodeQueryTimeout, resp.Code, problem)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestUsersPolicy(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	request := func(t testing.TB, policy pagination.Policy, target, rangeHeader string) (*httptest.ResponseRecorder, model.UsersPage, model.Problem) {
+		t.Helper()
+		registry := pagination.NewResourceRegistry(pagination.NewUsersPaginator(db, pagination.PaginatorConfig{Policy: policy}))
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		if err != nil {
+			t.Fatalf("request creation failed with error: %v", err)
+		}
+		if rangeHeader != "" {
+			req.Header.Set("Range", rangeHeader)
+		}
+
+		resp := httptest.NewRecorder()
+		NewUsersHttpController(registry).GetUsers(resp, req)
+
+		var payload struct {
+			model.Problem
+			Data model.UsersPage
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+			t.Fatalf("Error decoding JSON: %v", err)
+		}
+		return resp, payload.Data, payload.Problem
+	}
+
+	t.Run("lenient policy clamps", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+			WithArgs(5, 20).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname", "count"}).AddRow(21, "Jane", "Doe", 100))
+
+		resp, page, _ := request(t, pagination.Policy{MaxLimit: 5, MaxDepth: 20}, "/users?page=10&limit=50", "")
+		if resp.Code != http.StatusOK {
+			t.Errorf("expected code: %v, got %v", http.StatusOK, resp.Code)
+		}
+
+		want := []string{`299 - "limit 50 clamped to 5"`, `299 - "page 10 clamped to 5"`}
+		if got := resp.Header().Values("Warning"); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected warnings: %v, got %v", want, got)
+		}
+		if page.Links == nil || page.Links.Prev != "/users?limit=5&page=4&strategy=offset" {
+			t.Errorf("expected links with the applied limit, got %+v", page.Links)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("strict policy rejects", func(t *testing.T) {
+		strict := pagination.Policy{MaxLimit: 5, MaxDepth: 20, Mode: pagination.PolicyStrict}
+		testCases := []struct {
+			target string
+			header string
+			code   string
+			field  string
+		}{
+			{target: "/users?limit=50", code: CodeLimitOutOfRange, field: "limit"},
+			{target: "/users?limit=0", code: CodeLimitOutOfRange, field: "limit"},
+			{target: "/users?page=0", code: CodeOffsetOutOfRange, field: "page"},
+			{target: "/users?page=6&limit=5", code: CodeOffsetOutOfRange, field: "page"},
+			{target: "/users?strategy=range&offset=21", code: CodeOffsetOutOfRange, field: "offset"},
+			{target: "/users", header: "items=30-34", code: CodeOffsetOutOfRange, field: "offset"},
+			{target: "/users?pg=2", code: CodeInvalidParameter, field: "pg"},
+		}
+
+		for _, tc := range testCases {
+			resp, _, problem := request(t, strict, tc.target, tc.header)
+			if resp.Code != http.StatusBadRequest || problem.Code != tc.code || problem.Field != tc.field {
+				t.Errorf("%v: expected %v %v %v, got %v %v %v", tc.target, http.StatusBadRequest, tc.code, tc.field, resp.Code, problem.Code, problem.Field)
+			}
+		}
+	})
+}
@@ -9,42 +9,74 @@ import (
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 )
 
-type cursoBasedRepoInterface interface {
-	CursorBasedRead(ctx context.Context, cursor, limit int) (model.UsersData, error)
-	TotalUsers(ctx context.Context) (int, error)
+// CursorBasedHandler reads cursor based pages of users through the users Paginator.
+type CursorBasedHandler struct {
+	Users Paginator[model.UserData]
 }
 
-type CursorBasedHandler struct {
-	Repo cursoBasedRepoInterface
+// CursorBasedConfig holds the server side settings of the cursor based handler.
+type CursorBasedConfig struct {
+	// CursorSecret signs the cursor tokens.
+	CursorSecret string
+	// Sessions holds the snapshot sessions, snapshot reads fail when it is nil.
+	Sessions *repo.SnapshotSessions
+}
+
+// CursorRequest holds the params of a cursor based page request.
+// At most one of After and Before may be set, Sort is a sort param such as
+// "surname,name,-id". FromEnd reads the last page instead of the first one
+// when neither cursor is set.
+type CursorRequest struct {
+	After   string
+	Before  string
+	Sort    string
+	Filters []model.Filter
+	Limit   int
+	FromEnd bool
+	// Snapshot opens a snapshot session the page and later pages are read
+	// from, later pages pass the session token as Session.
+	Snapshot bool
+	Session  string
 }
 
-// NewCursorBasedHandler initializes a CursorBasedHandler with a database connection.
-func NewCursorBasedHandler(db *sql.DB) CursorBasedHandler {
-	repoHandler := repo.RepositoryHandler{Db: db}
+// NewCursorBasedHandler initializes a CursorBasedHandler with a database connection
+// and its server side settings.
+func NewCursorBasedHandler(db *sql.DB, cfg CursorBasedConfig) CursorBasedHandler {
 	return CursorBasedHandler{
-		Repo: repoHandler,
+		Users: NewUsersPaginator(db, PaginatorConfig{
+			CursorSecret: cfg.CursorSecret,
+			Sessions:     cfg.Sessions,
+		}),
 	}
 }
 
-// Retrieve fetches a paginated list of users using cursor-based pagination.
+// Retrieve fetches a paginated list of users using cursor-based pagination,
+// see Paginator.Keyset.
 // It returns a UsersCursorBasedMetaData struct containing the retrieved users
-// and the next cursor for subsequent queries.
-func (h CursorBasedHandler) Retrieve(ctx context.Context, cursor, limit int) (model.UsersCursorBasedMetaData, error) {
+// in display order together with the next and previous cursor tokens.
+func (h CursorBasedHandler) Retrieve(ctx context.Context, req CursorRequest) (model.UsersCursorBasedMetaData, error) {
 	// tracer span instance
 	tracerHander := pkg.TracerConfigHandler{}
 	ctx, span := tracerHander.TracerSpan(ctx, "cursor-domain", "domain: retrieve")
 	defer span.End()
 
 	var pgMetaData model.UsersCursorBasedMetaData
-
-	usersData, err := h.Repo.CursorBasedRead(ctx, cursor, limit)
+	page, err := h.Users.Keyset(ctx, req)
 	if err != nil {
 		span.RecordError(err) // Record error in span
 		return pgMetaData, err
 	}
 
-	nextCursor := usersData[len(usersData)-1].ID
-	pgMetaData.Users = usersData
-	pgMetaData.NextCursor = nextCursor
+	pgMetaData.Users = page.Items
+	pgMetaData.NextCursor = page.NextCursor
+	pgMetaData.PrevCursor = page.PrevCursor
+	pgMetaData.HasMore = page.HasMore
+	pgMetaData.Session = page.Session
 	return pgMetaData, nil
 }
+
+// EdgeCursors issues the cursor token pointing at every user of a page read
+// with req, in the sort the page was read in.
+func (h CursorBasedHandler) EdgeCursors(req CursorRequest, users model.UsersData) ([]string, error) {
+	return h.Users.EdgeCursors(req, users)
+}
@@ -0,0 +1,59 @@
+package pagination
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// DefaultExportBatchSize is the number of rows fetched per batch when no batch size is set.
+const DefaultExportBatchSize = 1000
+
+type exportRepoInterface interface {
+	ExportUsers(ctx context.Context, filters []model.Filter, batchSize int, emit func(model.UsersData) error) error
+}
+
+// ExportHandler streams whole filtered listings of users, batch by batch.
+type ExportHandler struct {
+	Repo      exportRepoInterface
+	BatchSize int
+}
+
+// NewExportHandler initializes an ExportHandler fetching batchSize rows at a
+// time, DefaultExportBatchSize when it isn't positive.
+func NewExportHandler(db *sql.DB, batchSize int) ExportHandler {
+	if batchSize < 1 {
+		batchSize = DefaultExportBatchSize
+	}
+	return ExportHandler{
+		Repo:      repo.RepositoryHandler{Db: db},
+		BatchSize: batchSize,
+	}
+}
+
+// Export passes every user matching the filters to emit, in id order and one
+// batch at a time. Cancelling ctx stops the export.
+func (h ExportHandler) Export(ctx context.Context, filters []model.Filter, emit func(model.UsersData) error) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "export-domain", "domain: export")
+	defer span.End()
+
+	if err := validateFilters(filters, repo.UserFilterColumns, repo.UserFilterColumns); err != nil {
+		return err
+	}
+
+	batchSize := h.BatchSize
+	if batchSize < 1 {
+		batchSize = DefaultExportBatchSize
+	}
+
+	if err := h.Repo.ExportUsers(ctx, filters, batchSize, emit); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package pagination
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// ErrInvalidFilter is returned when a filter names a column that can't be
+// filtered on, or matches a column that doesn't hold text by prefix or
+// contents.
+var ErrInvalidFilter = errors.New("invalid filter")
+
+// filterSuffixes maps the param suffix of every filter operator, `name=`
+// matches exactly, `name_prefix=` by prefix and `name_contains=` anywhere
+// in the value ignoring case.
+var filterSuffixes = []struct {
+	suffix string
+	op     string
+}{
+	{suffix: "", op: model.FilterEq},
+	{suffix: "_prefix", op: model.FilterPrefix},
+	{suffix: "_contains", op: model.FilterContains},
+}
+
+// ParseFilters collects the filters of the allowed columns from the request
+// query params. Params that are empty or belong to other columns are ignored.
+func ParseFilters(params map[string][]string, allowed []string) []model.Filter {
+	var filters []model.Filter
+	for _, column := range allowed {
+		for _, f := range filterSuffixes {
+			values := params[column+f.suffix]
+			if len(values) == 0 || values[0] == "" {
+				continue
+			}
+			filters = append(filters, model.Filter{Column: column, Op: f.op, Value: values[0]})
+		}
+	}
+	return filters
+}
+
+// FilterParams returns the name of every filter param of the allowed columns.
+func FilterParams(allowed []string) []string {
+	params := make([]string, 0, len(allowed)*len(filterSuffixes))
+	for _, column := range allowed {
+		for _, f := range filterSuffixes {
+			params = append(params, column+f.suffix)
+		}
+	}
+	return params
+}
+
+// validateFilters ensures every filter targets an allowed column with a known
+// operator, LIKE operators only targeting the text columns.
+func validateFilters(filters []model.Filter, allowed, text []string) error {
+	for _, filter := range filters {
+		if !slices.Contains(allowed, filter.Column) {
+			return &ParamError{Param: filter.Column, Err: ErrInvalidFilter}
+		}
+		switch filter.Op {
+		case model.FilterEq:
+		case model.FilterPrefix, model.FilterContains:
+			if !slices.Contains(text, filter.Column) {
+				return &ParamError{Param: filter.Column, Err: ErrInvalidFilter}
+			}
+		default:
+			return &ParamError{Param: filter.Column, Err: ErrInvalidFilter}
+		}
+	}
+	return nil
+}
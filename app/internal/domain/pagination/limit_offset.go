@@ -3,68 +3,179 @@ package pagination
 import (
 	"context"
 	"database/sql"
-	"math"
+	"errors"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/model"
 	"github.com/John-Dembaremba/pagination-technics/internal/repo"
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 )
 
-type repoInterface interface {
-	LimitOffsetRead(ctx context.Context, offset, limit int) (model.UsersData, error)
-	TotalUsers(ctx context.Context) (int, error)
-}
+// limit-offset read modes
+const (
+	// OffsetModePlain skips the offset over the full rows.
+	OffsetModePlain = "offset"
+	// OffsetModeDeferred skips the offset over an index-only scan of the ids
+	// and joins the page rows back afterwards ("late row lookup").
+	OffsetModeDeferred = "deferred"
+	// OffsetModeAdaptive skips the offset of shallow pages and serves pages
+	// past the adaptive threshold as keyset reads after the previous page.
+	OffsetModeAdaptive = "adaptive"
+)
+
+// DefaultAdaptiveThreshold is the depth, page times limit, past which
+// adaptive reads switch to keyset reads unless configured otherwise.
+const DefaultAdaptiveThreshold = 10000
+
+// reads that serve adaptive pages and pages seeked through the page index
+const (
+	ServedByOffset = "offset"
+	ServedByKeyset = "keyset"
+	ServedByIndex  = "index"
+)
+
+// count strategies of the total pages
+const (
+	// CountExact counts every matching row.
+	CountExact = "exact"
+	// CountEstimated uses the table statistics or the planner's row estimate.
+	CountEstimated = "estimated"
+	// CountNone skips counting and looks one row ahead for the next page.
+	CountNone = "none"
+)
+
+// ErrInvalidMode is returned when a request asks for an unknown read mode.
+var ErrInvalidMode = errors.New("invalid mode")
+
+// ErrInvalidCountMode is returned when a request asks for an unknown count strategy.
+var ErrInvalidCountMode = errors.New("invalid count mode")
+
+// ErrRangeNotSatisfiable is returned when an item range starts past the last user.
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
 
+// LimitOffSetHandler reads limit-offset pages of users through the users Paginator.
 type LimitOffSetHandler struct {
-	Repo repoInterface
+	Users  Paginator[model.UserData]
+	Config LimitOffsetConfig
 }
 
-// NewLimitOffSetHandler initializes a LimitOffSetHandler with the given database connection.
-func NewLimitOffSetHandler(db *sql.DB) LimitOffSetHandler {
-	repoHandler := repo.RepositoryHandler{Db: db}
+// LimitOffsetConfig holds the server side defaults of the limit-offset handler.
+type LimitOffsetConfig struct {
+	// Mode is the read mode of requests that don't pick one, OffsetModePlain when empty.
+	Mode string
+	// CountMode is the count strategy of requests that don't pick one, CountExact when empty.
+	CountMode string
+	// CountCache caches exact counts between requests, it may be nil.
+	CountCache *repo.CountCache
+	// Sessions holds the snapshot sessions, snapshot reads fail when it is nil.
+	Sessions *repo.SnapshotSessions
+	// AdaptiveThreshold is the depth past which adaptive reads switch to
+	// keyset reads, DefaultAdaptiveThreshold when zero.
+	AdaptiveThreshold int
+	// CursorSecret signs the cursors adaptive pages hand over.
+	CursorSecret string
+	// Boundaries is the users page index, it may be nil.
+	Boundaries *repo.PageIndex
+}
+
+// OffsetRequest holds the params of a limit-offset page request. Sort is a
+// sort param such as "surname,-id" and Filters restrict both the page and
+// the total count. RetrieveUsers reads from Page, RetrieveRange from the
+// zero based Offset.
+type OffsetRequest struct {
+	Page    int
+	Offset  int
+	Limit   int
+	Sort    string
+	Filters []model.Filter
+	Mode    string
+	Count   string
+	// After is the NextCursor of the previous page, adaptive reads of deep
+	// pages continue from it.
+	After string
+	// Snapshot opens a snapshot session the page and later pages are read
+	// from, later pages pass the session token as Session.
+	Snapshot bool
+	Session  string
+}
+
+// NewLimitOffSetHandler initializes a LimitOffSetHandler with the given database connection
+// and server side defaults.
+func NewLimitOffSetHandler(db *sql.DB, cfg LimitOffsetConfig) LimitOffSetHandler {
 	return LimitOffSetHandler{
-		Repo: repoHandler,
+		Users: NewUsersPaginator(db, PaginatorConfig{
+			Mode:       cfg.Mode,
+			CountMode:  cfg.CountMode,
+			CountCache: cfg.CountCache,
+			Sessions:   cfg.Sessions,
+
+			AdaptiveThreshold: cfg.AdaptiveThreshold,
+			CursorSecret:      cfg.CursorSecret,
+			Boundaries:        cfg.Boundaries,
+		}),
+		Config: cfg,
 	}
 }
 
-// RetrieveUsers fetches paginated user data based on the given page and limit values.
-func (h LimitOffSetHandler) RetrieveUsers(ctx context.Context, page, limit int) (model.UsersPaginationMetaData, error) {
+// RetrieveUsers fetches paginated user data based on the given page and limit values,
+// sorted and filtered as requested. The total pages only count the filtered users.
+func (h LimitOffSetHandler) RetrieveUsers(ctx context.Context, req OffsetRequest) (model.UsersPaginationMetaData, error) {
 	// tracer span instance
 	tracerHander := pkg.TracerConfigHandler{}
 	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-domain", "domain: retrieve")
 	defer span.End()
 
-	if page < 1 {
-		page = 1
-	}
-
 	var data model.UsersPaginationMetaData
-	var pg model.Pagination
-
-	offset := (page - 1) * limit
-	usersData, err := h.Repo.LimitOffsetRead(ctx, offset, limit)
+	page, err := h.Users.Offset(ctx, req)
 	if err != nil {
 		span.RecordError(err)
 		return data, err
 	}
 
-	totalUsers, err := h.Repo.TotalUsers(ctx)
+	data.Users = page.Items
+	data.Pagination = page.Pagination
+	data.Session = page.Session
+	return data, nil
+}
+
+// RetrieveRange fetches the users of an item range, Limit users from the
+// zero based Offset, through the same read as RetrieveUsers. A range starting
+// past the last user yields ErrRangeNotSatisfiable together with the total.
+func (h LimitOffSetHandler) RetrieveRange(ctx context.Context, req OffsetRequest) (model.UsersRangeData, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-domain", "domain: retrieve-range")
+	defer span.End()
+
+	page, err := h.Users.Range(ctx, req)
+	data := model.UsersRangeData{
+		Users:   page.Items,
+		First:   page.First,
+		Last:    page.Last,
+		Total:   page.Total,
+		Session: page.Session,
+	}
 	if err != nil {
+		span.RecordError(err)
 		return data, err
 	}
+	return data, nil
+}
 
-	totalPages := int(math.Ceil(float64(totalUsers) / float64(limit)))
-	nextPage := getNextPage(page+1, totalPages)
-	prevPage := getPrevPage(page-1, 1)
-
-	pg.CurrentPage = page
-	pg.TotalPages = totalPages
-	pg.NextPage = nextPage
-	pg.PrevPage = prevPage
+// CountUsers counts the users matching the filters with the configured count
+// strategy. A configuration that doesn't count counts exactly, since the
+// caller asked for the count.
+func (h LimitOffSetHandler) CountUsers(ctx context.Context, filters []model.Filter) (int, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-domain", "domain: count")
+	defer span.End()
 
-	data.Pagination = pg
-	data.Users = usersData
-	return data, nil
+	count, err := h.Users.Count(ctx, filters)
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	return count, nil
 }
 
 // getNextPage returns the next page number, ensuring it does not exceed the total pages.
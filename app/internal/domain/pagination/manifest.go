@@ -0,0 +1,255 @@
+package pagination
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultManifestKey is the key column of manifest resources that don't set one.
+const DefaultManifestKey = "id"
+
+// ErrInvalidManifest is returned for manifests that can't be served as written.
+var ErrInvalidManifest = errors.New("invalid resources manifest")
+
+// textTypes are the data types prefix and contains filters may match.
+var textTypes = []string{"text", "character varying", "character"}
+
+// identifier matches the table and column names a manifest may use, they
+// are written into queries as they are.
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Manifest lists the resources exposed without code of their own, it is
+// written in YAML or JSON:
+//
+//	resources:
+//	  - name: orders
+//	    table: orders
+//	    fields: [id, status, created_at]
+//	    sort: [created_at, status]
+//	    filters: [status]
+//	    max_page_size: 500
+//	    default_strategy: keyset
+type Manifest struct {
+	Resources []ManifestResource `yaml:"resources"`
+}
+
+// ManifestResource is a table listed in the manifest. Name, which defaults
+// to the table, names its route. Fields are the exposed columns, they must
+// hold the Key column and every Sort column. Filters are the filterable
+// columns. DefaultPageSize, MaxPageSize, MaxDepth and Params, PolicyLenient
+// or PolicyStrict, override the server wide pagination policy when set, and
+// DefaultStrategy, StrategyOffset when empty, serves requests naming none.
+// FilterTypes, filled in by CheckManifest, maps every filter column to its
+// data type.
+type ManifestResource struct {
+	Name            string   `yaml:"name"`
+	Table           string   `yaml:"table"`
+	Key             string   `yaml:"key"`
+	Fields          []string `yaml:"fields"`
+	Sort            []string `yaml:"sort"`
+	Filters         []string `yaml:"filters"`
+	DefaultPageSize int      `yaml:"default_page_size"`
+	MaxPageSize     int      `yaml:"max_page_size"`
+	MaxDepth        int      `yaml:"max_depth"`
+	Params          string   `yaml:"params"`
+	DefaultStrategy string   `yaml:"default_strategy"`
+
+	FilterTypes map[string]string `yaml:"-"`
+}
+
+// LoadManifest reads the manifest at path, JSON being read as the YAML it
+// is, and fills in the defaults of every resource.
+func LoadManifest(path string) (Manifest, error) {
+	var manifest Manifest
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return manifest, fmt.Errorf("failed to read resources manifest: %v", err)
+	}
+
+	if err := yaml.Unmarshal(content, &manifest); err != nil {
+		return manifest, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+
+	names := map[string]bool{}
+	for i := range manifest.Resources {
+		resource := &manifest.Resources[i]
+		if resource.Name == "" {
+			resource.Name = resource.Table
+		}
+		if resource.Key == "" {
+			resource.Key = DefaultManifestKey
+		}
+		if resource.DefaultStrategy == "" {
+			resource.DefaultStrategy = StrategyOffset
+		}
+
+		if err := resource.validate(); err != nil {
+			return manifest, err
+		}
+		if names[resource.Name] {
+			return manifest, fmt.Errorf("%w: resource %v is listed twice", ErrInvalidManifest, resource.Name)
+		}
+		names[resource.Name] = true
+	}
+	return manifest, nil
+}
+
+// validate checks the resource is consistent on its own.
+func (m ManifestResource) validate() error {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: resource %v: %v", ErrInvalidManifest, m.Name, fmt.Sprintf(format, args...))
+	}
+
+	if !identifier.MatchString(m.Name) {
+		return invalid("name must be a lower case identifier")
+	}
+	if !identifier.MatchString(m.Table) {
+		return invalid("table %q must be a lower case identifier", m.Table)
+	}
+	for _, field := range m.Fields {
+		if !identifier.MatchString(field) {
+			return invalid("field %q must be a lower case identifier", field)
+		}
+	}
+	if !slices.Contains(m.Fields, m.Key) {
+		return invalid("key %v must be an exposed field", m.Key)
+	}
+	for _, column := range m.Sort {
+		if !slices.Contains(m.Fields, column) {
+			return invalid("sort field %v must be an exposed field", column)
+		}
+	}
+	for _, column := range m.Filters {
+		if !slices.Contains(m.Fields, column) {
+			return invalid("filter field %v must be an exposed field", column)
+		}
+	}
+	if m.DefaultPageSize < 0 || m.MaxPageSize < 0 || m.MaxDepth < 0 {
+		return invalid("page sizes and max depth must not be negative")
+	}
+	if m.MaxPageSize > 0 && m.DefaultPageSize > m.MaxPageSize {
+		return invalid("default page size exceeds max page size")
+	}
+	switch m.Params {
+	case "", PolicyLenient, PolicyStrict:
+	default:
+		return invalid("unknown params mode %v", m.Params)
+	}
+
+	switch m.DefaultStrategy {
+	case StrategyOffset, StrategyKeyset, StrategyRange:
+	default:
+		return invalid("unknown default strategy %v", m.DefaultStrategy)
+	}
+	return nil
+}
+
+// sortColumns returns the sortable columns of the resource, which always
+// include its key.
+func (m ManifestResource) sortColumns() []string {
+	if slices.Contains(m.Sort, m.Key) {
+		return m.Sort
+	}
+	return append(slices.Clone(m.Sort), m.Key)
+}
+
+// textFilters returns the filter columns of a text type, the only ones
+// matched by prefix or contents.
+func (m ManifestResource) textFilters() []string {
+	var columns []string
+	for _, column := range m.Filters {
+		if slices.Contains(textTypes, m.FilterTypes[column]) {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// CheckManifest confirms every listed table exists with all its fields, that
+// its key leads a unique single column index and that every sort field leads
+// an index, so no listing falls back to sorting the whole table. The key and
+// sort fields must be NOT NULL, cursors hold their values and keyset
+// predicates never match a NULL. The data type of every filter field is
+// recorded in its resource.
+func CheckManifest(ctx context.Context, db *sql.DB, manifest *Manifest) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "manifest-domain", "domain: check")
+	defer span.End()
+
+	repoHandler := repo.RepositoryHandler{Db: db}
+	for i := range manifest.Resources {
+		resource := &manifest.Resources[i]
+		columns, err := repoHandler.TableColumns(ctx, resource.Table)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if len(columns) == 0 {
+			return fmt.Errorf("%w: resource %v: table %v doesn't exist", ErrInvalidManifest, resource.Name, resource.Table)
+		}
+		described := make(map[string]repo.Column, len(columns))
+		for _, column := range columns {
+			described[column.Name] = column
+		}
+		for _, field := range resource.Fields {
+			if _, ok := described[field]; !ok {
+				return fmt.Errorf("%w: resource %v: column %v.%v doesn't exist", ErrInvalidManifest, resource.Name, resource.Table, field)
+			}
+		}
+
+		indexed, err := repoHandler.IndexedColumns(ctx, resource.Table)
+		if err != nil {
+			span.RecordError(err)
+			return err
+		}
+		if !indexed[resource.Key] {
+			return fmt.Errorf("%w: resource %v: key %v has no unique index", ErrInvalidManifest, resource.Name, resource.Key)
+		}
+		for _, column := range resource.Sort {
+			if _, ok := indexed[column]; !ok {
+				return fmt.Errorf("%w: resource %v: sort field %v doesn't lead an index", ErrInvalidManifest, resource.Name, column)
+			}
+		}
+		for _, column := range resource.sortColumns() {
+			if described[column].Nullable {
+				return fmt.Errorf("%w: resource %v: sort field %v must be NOT NULL", ErrInvalidManifest, resource.Name, column)
+			}
+		}
+
+		resource.FilterTypes = make(map[string]string, len(resource.Filters))
+		for _, column := range resource.Filters {
+			resource.FilterTypes[column] = described[column].DataType
+		}
+	}
+	return nil
+}
+
+// NewManifestRegistry registers every strategy of a manifest resource with
+// the given server side settings, its pagination policy and default strategy.
+func NewManifestRegistry(db *sql.DB, resource ManifestResource, cfg PaginatorConfig) (*Registry[model.Record], error) {
+	cfg.Policy = cfg.Policy.Override(Policy{
+		DefaultLimit: resource.DefaultPageSize,
+		MaxLimit:     resource.MaxPageSize,
+		MaxDepth:     resource.MaxDepth,
+		Mode:         resource.Params,
+	})
+	paginator := NewPaginator(db, repo.RecordResource(resource.Table, resource.Key, resource.Fields, resource.sortColumns(), resource.Filters, resource.textFilters()), cfg)
+
+	registry := NewResourceRegistry(paginator)
+	if err := registry.SetFallback(resource.DefaultStrategy); err != nil {
+		return nil, err
+	}
+	return registry, nil
+}
@@ -0,0 +1,200 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// DefaultPageLimit is the page size of a paginator request without a limit.
+const DefaultPageLimit = 20
+
+var (
+	// ErrUnknownStrategy is returned when a request names an unregistered strategy.
+	ErrUnknownStrategy = errors.New("unknown strategy")
+	// ErrInvalidLimit is returned when a request limit isn't a number.
+	ErrInvalidLimit = errors.New("invalid limit")
+	// ErrLimitOutOfRange is returned when a request limit is a number no page can have.
+	ErrLimitOutOfRange = errors.New("limit out of range")
+	// ErrInvalidPosition is returned when a page position can't be read by its strategy.
+	ErrInvalidPosition = errors.New("invalid position")
+	// ErrInvalidSnapshot is returned when the snapshot param isn't a boolean.
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+)
+
+// ParamError is returned for a request param that can't be served. Param
+// names the param, a filter names its column, and Err is the sentinel error
+// of the problem, which errors.Is matches.
+type ParamError struct {
+	Param string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Err, e.Param)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// PaginateFunc reads the page of a strategy independent request.
+type PaginateFunc[T any] func(ctx context.Context, req PageRequest) (Page[T], error)
+
+// ParamParser turns the query params and headers of a request into the
+// PageRequest of its strategy.
+type ParamParser func(params url.Values, header http.Header) (PageRequest, error)
+
+// PageRequest is a page request every strategy understands. Position is where
+// the page starts in the terms of its strategy, a page number, a cursor token
+// or an item offset, and empty reads the first page. Backward reads the page
+// ending at Position instead. Mode and Count only apply to limit-offset reads.
+type PageRequest struct {
+	Position string
+	// After is the cursor adaptive limit-offset reads of deep pages continue from.
+	After    string
+	Backward bool
+	Limit    int
+	Sort     string
+	Filters  []model.Filter
+	Mode     string
+	Count    string
+	Snapshot bool
+	Session  string
+	// Adjustments are the params the policy of the paginator clamped.
+	Adjustments []*Adjustment
+}
+
+// Page is a page of items in display order. Next, Prev and Last hold the
+// query params addressing those pages, nil when they don't exist or are
+// unknown. Offset is the zero based position of the first item for the
+// strategies that know it and Total counts every matching item when counted.
+// ServedBy is the read that served an adaptive or index seeked limit-offset
+// page and IndexAge the age of the page index it seeked.
+type Page[T any] struct {
+	Items    []T
+	ServedBy string
+	IndexAge string
+	Next     map[string]string
+	Prev     map[string]string
+	Last     map[string]string
+	Offset   *int
+	Total    *int
+	Session  *model.SnapshotSession
+}
+
+// Strategy is a registered pagination strategy. PositionParams are the query
+// params its parser reads positions from, links to other pages replace them.
+type Strategy[T any] struct {
+	Name           string
+	Paginate       PaginateFunc[T]
+	Parse          ParamParser
+	PositionParams []string
+}
+
+// Registry holds the pagination strategies of a resource by name.
+type Registry[T any] struct {
+	fallback   string
+	strategies map[string]Strategy[T]
+}
+
+// NewRegistry initializes an empty Registry, requests that don't name a
+// strategy use the fallback one.
+func NewRegistry[T any](fallback string) *Registry[T] {
+	return &Registry[T]{
+		fallback:   fallback,
+		strategies: map[string]Strategy[T]{},
+	}
+}
+
+// Register adds a strategy, replacing any strategy of the same name.
+func (r *Registry[T]) Register(strategy Strategy[T]) {
+	r.strategies[strategy.Name] = strategy
+}
+
+// Lookup returns the strategy of the given name, the fallback one when the
+// name is empty.
+func (r *Registry[T]) Lookup(name string) (Strategy[T], error) {
+	if name == "" {
+		name = r.fallback
+	}
+
+	strategy, ok := r.strategies[name]
+	if !ok {
+		return strategy, ErrUnknownStrategy
+	}
+	return strategy, nil
+}
+
+// SetFallback makes the named strategy the one of requests that don't name
+// a strategy, it must be registered.
+func (r *Registry[T]) SetFallback(name string) error {
+	if _, ok := r.strategies[name]; !ok {
+		return ErrUnknownStrategy
+	}
+	r.fallback = name
+	return nil
+}
+
+// Names returns the names of every registered strategy in alphabetical order.
+func (r *Registry[T]) Names() []string {
+	names := make([]string, 0, len(r.strategies))
+	for name := range r.strategies {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// pageParams are the params every strategy reads, besides its position
+// params and the filter params of the resource.
+var pageParams = []string{"strategy", "limit", "sort", "mode", "count", "snapshot", "session"}
+
+// parsePageParams parses the params shared by every strategy, leaving the
+// position to the strategy parser. The limit is bounded by the policy of the
+// paginator, which rejects params that are neither shared nor one of the
+// position params of the strategy when it is strict.
+func (p Paginator[T]) parsePageParams(params url.Values, positionParams []string) (PageRequest, error) {
+	policy := p.Config.Policy
+	req := PageRequest{
+		Sort:    params.Get("sort"),
+		Filters: ParseFilters(params, p.Resource.FilterColumns),
+		Mode:    params.Get("mode"),
+		Count:   params.Get("count"),
+		Session: params.Get("session"),
+	}
+
+	known := slices.Concat(pageParams, positionParams, FilterParams(p.Resource.FilterColumns))
+	if err := policy.CheckParams(params, known); err != nil {
+		return req, err
+	}
+
+	limit, adjusted, err := policy.ParseLimit("limit", params.Get("limit"))
+	if err != nil {
+		return req, err
+	}
+	req.Limit = limit
+	req.adjust(adjusted)
+
+	if snapshot := params.Get("snapshot"); snapshot != "" {
+		snapshotBool, err := strconv.ParseBool(snapshot)
+		if err != nil {
+			return req, &ParamError{Param: "snapshot", Err: ErrInvalidSnapshot}
+		}
+		req.Snapshot = snapshotBool
+	}
+	return req, nil
+}
+
+// adjust records a param the policy clamped, if any.
+func (r *PageRequest) adjust(adjusted *Adjustment) {
+	if adjusted != nil {
+		r.Adjustments = append(r.Adjustments, adjusted)
+	}
+}
@@ -0,0 +1,241 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// modes of a pagination policy
+const (
+	// PolicyLenient ignores unknown params and clamps out of range values
+	// into range, reporting every clamped value.
+	PolicyLenient = "lenient"
+	// PolicyStrict rejects unknown params and out of range values.
+	PolicyStrict = "strict"
+)
+
+// DefaultMaxPageLimit is the largest page size of a policy that doesn't set one.
+const DefaultMaxPageLimit = 1000
+
+var (
+	// ErrOffsetOutOfRange is returned when a page or offset lies before the
+	// first item or deeper than the policy allows.
+	ErrOffsetOutOfRange = errors.New("offset out of range")
+	// ErrUnknownParam is returned by strict policies for params no endpoint reads.
+	ErrUnknownParam = errors.New("unknown param")
+	// ErrInvalidPolicy is returned for policy specs that can't be parsed.
+	ErrInvalidPolicy = errors.New("invalid pagination policy")
+)
+
+// Policy holds the page size and depth bounds of an endpoint and how it
+// treats params outside of them. The zero Policy is a lenient one with the
+// default page sizes and no depth bound.
+type Policy struct {
+	// DefaultLimit is the page size of requests without a limit, DefaultPageLimit when zero.
+	DefaultLimit int
+	// MaxLimit is the largest page size, DefaultMaxPageLimit when zero.
+	MaxLimit int
+	// MaxDepth is the largest number of items a page may skip, zero doesn't bound it.
+	MaxDepth int
+	// Mode is PolicyLenient or PolicyStrict, PolicyLenient when empty.
+	Mode string
+}
+
+// Adjustment is a param value a lenient policy clamped into range.
+type Adjustment struct {
+	Param     string
+	Requested int
+	Applied   int
+}
+
+func (a Adjustment) String() string {
+	return fmt.Sprintf("%v %v clamped to %v", a.Param, a.Requested, a.Applied)
+}
+
+// ParsePolicy parses a comma separated policy spec such as
+// "default_limit=20,max_limit=100,max_depth=10000,mode=strict". Settings
+// missing from the spec are left zero, an empty spec is the zero Policy.
+func ParsePolicy(spec string) (Policy, error) {
+	var p Policy
+	for _, setting := range strings.Split(spec, ",") {
+		setting = strings.TrimSpace(setting)
+		if setting == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(setting, "=")
+		if !found {
+			return p, fmt.Errorf("%w: %q is not a key=value setting", ErrInvalidPolicy, setting)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+
+		if key == "mode" {
+			if value != PolicyLenient && value != PolicyStrict {
+				return p, fmt.Errorf("%w: unknown mode %v", ErrInvalidPolicy, value)
+			}
+			p.Mode = value
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%w: %v must be a non negative number", ErrInvalidPolicy, key)
+		}
+		switch key {
+		case "default_limit":
+			p.DefaultLimit = n
+		case "max_limit":
+			p.MaxLimit = n
+		case "max_depth":
+			p.MaxDepth = n
+		default:
+			return p, fmt.Errorf("%w: unknown setting %v", ErrInvalidPolicy, key)
+		}
+	}
+
+	if p.DefaultLimit > 0 && p.MaxLimit > 0 && p.DefaultLimit > p.MaxLimit {
+		return p, fmt.Errorf("%w: default_limit exceeds max_limit", ErrInvalidPolicy)
+	}
+	return p, nil
+}
+
+// Override returns the policy with the settings o sets replacing its own,
+// endpoints override the server wide policy this way.
+func (p Policy) Override(o Policy) Policy {
+	if o.DefaultLimit > 0 {
+		p.DefaultLimit = o.DefaultLimit
+	}
+	if o.MaxLimit > 0 {
+		p.MaxLimit = o.MaxLimit
+	}
+	if o.MaxDepth > 0 {
+		p.MaxDepth = o.MaxDepth
+	}
+	if o.Mode != "" {
+		p.Mode = o.Mode
+	}
+	return p
+}
+
+// PageSize returns the page size of requests without a limit.
+func (p Policy) PageSize() int {
+	if p.DefaultLimit > 0 {
+		return min(p.DefaultLimit, p.maxLimit())
+	}
+	return min(DefaultPageLimit, p.maxLimit())
+}
+
+// ParseLimit reads the page size param named param, the default page size
+// when it is empty, see Limit.
+func (p Policy) ParseLimit(param, value string) (int, *Adjustment, error) {
+	if value == "" {
+		return p.PageSize(), nil, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, nil, &ParamError{Param: param, Err: ErrInvalidLimit}
+	}
+	return p.Limit(param, limit)
+}
+
+// Limit bounds the page size of the param named param to between one and
+// the max page size.
+func (p Policy) Limit(param string, limit int) (int, *Adjustment, error) {
+	return p.clamp(param, limit, 1, p.maxLimit(), ErrLimitOutOfRange)
+}
+
+// ParsePage reads a page number param of pages of limit items, the first
+// page when it is empty, see Page.
+func (p Policy) ParsePage(value string, limit int) (int, *Adjustment, error) {
+	if value == "" {
+		return 1, nil, nil
+	}
+
+	page, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, nil, &ParamError{Param: "page", Err: ErrInvalidPosition}
+	}
+	return p.Page(page, limit)
+}
+
+// Page bounds a page number of pages of limit items to between the first
+// page and the deepest page within the max depth.
+func (p Policy) Page(page, limit int) (int, *Adjustment, error) {
+	last := math.MaxInt
+	if p.MaxDepth > 0 && limit > 0 {
+		last = p.MaxDepth/limit + 1
+	}
+	return p.clamp("page", page, 1, last, ErrOffsetOutOfRange)
+}
+
+// ParseOffset reads a zero based item offset param, the first item when it
+// is empty, see Offset.
+func (p Policy) ParseOffset(value string) (int, *Adjustment, error) {
+	if value == "" {
+		return 0, nil, nil
+	}
+
+	offset, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, nil, &ParamError{Param: "offset", Err: ErrInvalidPosition}
+	}
+	return p.Offset(offset)
+}
+
+// Offset bounds a zero based item offset to between the first item and the
+// max depth.
+func (p Policy) Offset(offset int) (int, *Adjustment, error) {
+	last := math.MaxInt
+	if p.MaxDepth > 0 {
+		last = p.MaxDepth
+	}
+	return p.clamp("offset", offset, 0, last, ErrOffsetOutOfRange)
+}
+
+// CheckParams rejects the first param, in alphabetical order, that isn't
+// one of the known params when the policy is strict.
+func (p Policy) CheckParams(params url.Values, known []string) error {
+	if p.Mode != PolicyStrict {
+		return nil
+	}
+
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			return &ParamError{Param: name, Err: ErrUnknownParam}
+		}
+	}
+	return nil
+}
+
+// clamp bounds the value of the param to [low, high], rejecting values out
+// of it with err when the policy is strict.
+func (p Policy) clamp(param string, value, low, high int, err error) (int, *Adjustment, error) {
+	if value >= low && value <= high {
+		return value, nil, nil
+	}
+	if p.Mode == PolicyStrict {
+		return 0, nil, &ParamError{Param: param, Err: err}
+	}
+
+	applied := min(max(value, low), high)
+	return applied, &Adjustment{Param: param, Requested: value, Applied: applied}, nil
+}
+
+func (p Policy) maxLimit() int {
+	if p.MaxLimit > 0 {
+		return p.MaxLimit
+	}
+	return DefaultMaxPageLimit
+}
@@ -0,0 +1,688 @@
+package pagination
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+type resourceRepoInterface[T any] interface {
+	LimitOffsetRead(ctx context.Context, query model.OffsetQuery) ([]T, error)
+	LimitOffsetReadWithTotal(ctx context.Context, query model.OffsetQuery) ([]T, int, error)
+	Total(ctx context.Context, filters []model.Filter) (int, error)
+	Estimated(ctx context.Context, filters []model.Filter) (int, error)
+	CursorBasedRead(ctx context.Context, query model.KeysetQuery) ([]T, bool, error)
+	SortKeysAt(ctx context.Context, query model.OffsetQuery) ([]string, error)
+	Budgeted(ctx context.Context, read func(ctx context.Context) error) error
+}
+
+// Paginator pages through the resource it describes with the limit-offset
+// and keyset strategies. Sorts and filters are checked against the columns
+// of the resource and every sort ends with its key column.
+type Paginator[T any] struct {
+	Repo     resourceRepoInterface[T]
+	Resource repo.Resource[T]
+	Codec    CursorCodec
+	Config   PaginatorConfig
+}
+
+// PaginatorConfig holds the server side settings of a Paginator.
+type PaginatorConfig struct {
+	// Mode is the read mode of limit-offset requests that don't pick one, OffsetModePlain when empty.
+	Mode string
+	// CountMode is the count strategy of requests that don't pick one, CountExact when empty.
+	CountMode string
+	// CountCache caches exact counts of the resource between requests, it may be nil.
+	CountCache *repo.CountCache
+	// Sessions holds the snapshot sessions, snapshot reads fail when it is nil.
+	Sessions *repo.SnapshotSessions
+	// CursorSecret signs the cursor tokens.
+	CursorSecret string
+	// AdaptiveThreshold is the depth, page times limit, past which adaptive
+	// reads switch to keyset reads, DefaultAdaptiveThreshold when zero.
+	AdaptiveThreshold int
+	// Boundaries is the page index of the resource table, key sorted offset
+	// reads seek its boundaries when it is set.
+	Boundaries *repo.PageIndex
+	// Policy bounds the page sizes and positions of the registered strategies.
+	Policy Policy
+}
+
+// OffsetPage is a page numbered page of a resource.
+type OffsetPage[T any] struct {
+	Items      []T
+	Pagination model.Pagination
+	Session    *model.SnapshotSession
+}
+
+// RangePage is an item range of a resource. First and Last are the zero based
+// positions of its first and last item, Total is nil when nothing was counted.
+type RangePage[T any] struct {
+	Items   []T
+	First   int
+	Last    int
+	Total   *int
+	Session *model.SnapshotSession
+}
+
+// KeysetPage is a cursor based page of a resource. HasMore reports whether
+// more rows exist past this page in the direction it was read, the cursors
+// are nil when there is nothing to navigate to.
+type KeysetPage[T any] struct {
+	Items      []T
+	NextCursor *string
+	PrevCursor *string
+	HasMore    bool
+	Session    *model.SnapshotSession
+}
+
+// NewPaginator initializes a Paginator of the resource with the given
+// database connection and server side settings.
+func NewPaginator[T any](db *sql.DB, resource repo.Resource[T], cfg PaginatorConfig) Paginator[T] {
+	repoHandler := repo.RepositoryHandler{Db: db, Counts: cfg.CountCache}
+	return Paginator[T]{
+		Repo:     repo.NewResourceRepo(repoHandler, resource),
+		Resource: resource,
+		Codec:    NewCursorCodec(cfg.CursorSecret),
+		Config:   cfg,
+	}
+}
+
+// NewUsersPaginator initializes the Paginator of the users resource.
+func NewUsersPaginator(db *sql.DB, cfg PaginatorConfig) Paginator[model.UserData] {
+	return NewPaginator(db, repo.UsersResource, cfg)
+}
+
+// Offset reads the page numbered page of the request, sorted and filtered as
+// requested. The total pages only count the filtered items. Adaptive reads
+// serve pages past the adaptive threshold as keyset reads continuing from the
+// last row of the previous page, see readAfterPrevPage. Pages seeked through
+// the page index report the age of the index.
+func (p Paginator[T]) Offset(ctx context.Context, req OffsetRequest) (OffsetPage[T], error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "paginator-domain", "domain: offset "+p.Resource.Table)
+	defer span.End()
+
+	page, limit := req.Page, req.Limit
+	if page < 1 {
+		page = 1
+	}
+
+	var data OffsetPage[T]
+	var pg model.Pagination
+
+	if err := checkLimit(limit); err != nil {
+		span.RecordError(err)
+		return data, err
+	}
+
+	sort, mode, _, err := p.offsetSettings(req)
+	if err != nil {
+		span.RecordError(err)
+		return data, err
+	}
+
+	var result offsetRead[T]
+	if mode == OffsetModeAdaptive && page > 1 && page*limit > p.adaptiveThreshold() {
+		result, err = p.readAfterPrevPage(ctx, req, page)
+		pg.ServedBy = ServedByKeyset
+	} else {
+		result, err = p.readOffset(ctx, req, (page-1)*limit)
+		if mode == OffsetModeAdaptive {
+			pg.ServedBy = ServedByOffset
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+		return data, err
+	}
+	if !result.indexedAt.IsZero() {
+		pg.ServedBy = ServedByIndex
+		pg.IndexAge = time.Since(result.indexedAt).Round(time.Second).String()
+	}
+
+	pg.CurrentPage = page
+	pg.PrevPage = getPrevPage(page-1, 1)
+	pg.CountMode = result.countMode
+
+	if result.countMode == CountNone {
+		pg.NextPage = page
+		if result.hasMore {
+			pg.NextPage = page + 1
+		}
+	} else {
+		pg.TotalPages = int(math.Ceil(float64(result.total) / float64(limit)))
+		pg.NextPage = getNextPage(page+1, pg.TotalPages)
+	}
+
+	// adaptive pages hand over the cursor the next page may continue from
+	if mode == OffsetModeAdaptive && pg.NextPage > page && len(result.items) > 0 {
+		cursor := p.cursor(result.items[len(result.items)-1], sort)
+		cursor.Page = page
+		nextCursor, err := p.Codec.Encode(cursor)
+		if err != nil {
+			span.RecordError(err)
+			return data, err
+		}
+		pg.NextCursor = &nextCursor
+	}
+
+	data.Pagination = pg
+	data.Items = result.items
+	data.Session = result.session
+	return data, nil
+}
+
+// Range reads the items of an item range, Limit items from the zero based
+// Offset, through the same read as Offset. A range starting past the last
+// item yields ErrRangeNotSatisfiable together with the total.
+func (p Paginator[T]) Range(ctx context.Context, req OffsetRequest) (RangePage[T], error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "paginator-domain", "domain: range "+p.Resource.Table)
+	defer span.End()
+
+	var data RangePage[T]
+	if err := checkLimit(req.Limit); err != nil {
+		span.RecordError(err)
+		return data, err
+	}
+	if req.Offset < 0 {
+		req.Offset = 0
+	}
+
+	result, err := p.readOffset(ctx, req, req.Offset)
+	if err != nil {
+		span.RecordError(err)
+		return data, err
+	}
+
+	if result.countMode != CountNone {
+		total := result.total
+		data.Total = &total
+	}
+
+	// an empty listing is only satisfiable from its start
+	if len(result.items) == 0 && req.Offset > 0 {
+		span.RecordError(ErrRangeNotSatisfiable)
+		return data, ErrRangeNotSatisfiable
+	}
+
+	data.Items = result.items
+	data.First = req.Offset
+	data.Last = req.Offset + len(result.items) - 1
+	data.Session = result.session
+	return data, nil
+}
+
+// Count counts the items matching the filters with the configured count
+// strategy. A configuration that doesn't count counts exactly, since the
+// caller asked for the count.
+func (p Paginator[T]) Count(ctx context.Context, filters []model.Filter) (int, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "paginator-domain", "domain: count "+p.Resource.Table)
+	defer span.End()
+
+	if err := validateFilters(filters, p.Resource.FilterColumns, p.Resource.TextColumns); err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	countMode, err := p.countMode("")
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+
+	var count int
+	if countMode == CountEstimated {
+		count, err = p.Repo.Estimated(ctx, filters)
+	} else {
+		count, err = p.Repo.Total(ctx, filters)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return 0, err
+	}
+	return count, nil
+}
+
+// Keyset reads the cursor based page of the request. The page is read after
+// the `After` cursor token or before the `Before` cursor token and when both
+// are empty the first page, or with FromEnd the last page, is returned.
+// Tokens must have been issued by this paginator for the same sort,
+// otherwise ErrInvalidCursor is returned. A request without a sort keeps the
+// sort of its cursor. A page past either end of the list is empty rather
+// than an error.
+func (p Paginator[T]) Keyset(ctx context.Context, req CursorRequest) (KeysetPage[T], error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "paginator-domain", "domain: keyset "+p.Resource.Table)
+	defer span.End()
+
+	var data KeysetPage[T]
+
+	if err := checkLimit(req.Limit); err != nil {
+		span.RecordError(err) // Record error in span
+		return data, err
+	}
+
+	if req.After != "" && req.Before != "" {
+		err := &ParamError{Param: "before", Err: ErrInvalidCursor}
+		span.RecordError(err) // Record error in span
+		return data, err
+	}
+
+	if err := validateFilters(req.Filters, p.Resource.FilterColumns, p.Resource.TextColumns); err != nil {
+		span.RecordError(err) // Record error in span
+		return data, err
+	}
+
+	query := model.KeysetQuery{
+		Limit:    req.Limit,
+		Filters:  req.Filters,
+		Backward: req.Before != "" || (req.FromEnd && req.After == ""),
+	}
+	cursorToken, cursorParam := req.After, "after"
+	if query.Backward {
+		cursorToken, cursorParam = req.Before, "before"
+	}
+
+	sort, keys, err := p.decodeCursor(cursorToken, req.Sort)
+	if errors.Is(err, ErrInvalidCursor) {
+		err = &ParamError{Param: cursorParam, Err: err}
+	}
+	if err != nil {
+		span.RecordError(err) // Record error in span
+		return data, err
+	}
+	query.Sort = sort
+	query.Keys = keys
+
+	var items []T
+	var hasMore bool
+	session, err := p.read(ctx, req.Snapshot, req.Session, func(ctx context.Context) error {
+		var err error
+		items, hasMore, err = p.Repo.CursorBasedRead(ctx, query)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err) // Record error in span
+		return data, err
+	}
+
+	data.Items = []T{}
+	data.HasMore = hasMore
+	data.Session = session
+	if len(items) == 0 {
+		return data, nil
+	}
+	data.Items = items
+
+	// a backward read has rows after it unless it is the last page, a
+	// forward read only when it is not the last page
+	if (query.Backward && cursorToken != "") || (!query.Backward && hasMore) {
+		nextCursor, err := p.encodeCursor(items[len(items)-1], sort)
+		if err != nil {
+			span.RecordError(err) // Record error in span
+			return data, err
+		}
+		data.NextCursor = &nextCursor
+	}
+
+	// a forward read has rows before it unless it is the first page, a
+	// backward read only when it is not the first page
+	if (!query.Backward && cursorToken != "") || (query.Backward && hasMore) {
+		prevCursor, err := p.encodeCursor(items[0], sort)
+		if err != nil {
+			span.RecordError(err) // Record error in span
+			return data, err
+		}
+		data.PrevCursor = &prevCursor
+	}
+
+	return data, nil
+}
+
+// EdgeCursors issues the cursor token pointing at every item of a page read
+// with req, in the sort the page was read in.
+func (p Paginator[T]) EdgeCursors(req CursorRequest, items []T) ([]string, error) {
+	cursorToken := req.After
+	if req.Before != "" {
+		cursorToken = req.Before
+	}
+
+	sort, _, err := p.decodeCursor(cursorToken, req.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	cursors := make([]string, 0, len(items))
+	for _, item := range items {
+		cursor, err := p.encodeCursor(item, sort)
+		if err != nil {
+			return nil, err
+		}
+		cursors = append(cursors, cursor)
+	}
+	return cursors, nil
+}
+
+// offsetRead is the outcome of a limit-offset read. total is left at zero
+// when countMode is CountNone, hasMore is only set then.
+type offsetRead[T any] struct {
+	items     []T
+	total     int
+	hasMore   bool
+	countMode string
+	session   *model.SnapshotSession
+	// indexedAt is when the page index the read seeked was built, zero
+	// when the read didn't seek one
+	indexedAt time.Time
+}
+
+// offsetSettings resolves the sort, read mode and count strategy of a
+// limit-offset request. Requests without a sort are read in key order.
+func (p Paginator[T]) offsetSettings(req OffsetRequest) ([]model.SortField, string, string, error) {
+	rawSort := req.Sort
+	if rawSort == "" {
+		rawSort = p.Resource.Key
+	}
+	sort, err := parseSort(rawSort, p.Resource.SortColumns, p.Resource.Key)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	if err := validateFilters(req.Filters, p.Resource.FilterColumns, p.Resource.TextColumns); err != nil {
+		return nil, "", "", err
+	}
+
+	mode, err := p.readMode(req.Mode)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	countMode, err := p.countMode(req.Count)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return sort, mode, countMode, nil
+}
+
+// readOffset reads req.Limit items from offset along with their count, in the
+// sort, filters, mode and count strategy of the request. Adaptive requests
+// read plain offsets here.
+func (p Paginator[T]) readOffset(ctx context.Context, req OffsetRequest, offset int) (offsetRead[T], error) {
+	var result offsetRead[T]
+	limit := req.Limit
+
+	sort, mode, countMode, err := p.offsetSettings(req)
+	if err != nil {
+		return result, err
+	}
+	result.countMode = countMode
+
+	// without a count the next page is found by looking one row ahead
+	readLimit := limit
+	if countMode == CountNone {
+		readLimit++
+	}
+
+	query := model.OffsetQuery{
+		Offset:   offset,
+		Limit:    readLimit,
+		Sort:     sort,
+		Filters:  req.Filters,
+		Deferred: mode == OffsetModeDeferred,
+	}
+	if p.seekable(req, sort) {
+		var ok bool
+		query.From, query.Offset, result.indexedAt, ok = p.Config.Boundaries.Seek(offset)
+		if !ok {
+			query.From, query.Offset = "", offset
+		}
+	}
+
+	result.session, err = p.read(ctx, req.Snapshot, req.Session, func(ctx context.Context) error {
+		var err error
+		switch countMode {
+		case CountExact:
+			if query.From != "" {
+				// a window count would only count the rows past the boundary
+				result.items, err = p.Repo.LimitOffsetRead(ctx, query)
+				if err == nil {
+					result.total, err = p.Repo.Total(ctx, req.Filters)
+				}
+				break
+			}
+			// page and count in one round trip so they can't drift apart
+			result.items, result.total, err = p.Repo.LimitOffsetReadWithTotal(ctx, query)
+		case CountEstimated:
+			result.items, err = p.Repo.LimitOffsetRead(ctx, query)
+			if err == nil {
+				result.total, err = p.Repo.Estimated(ctx, req.Filters)
+			}
+		case CountNone:
+			result.items, err = p.Repo.LimitOffsetRead(ctx, query)
+		}
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if countMode == CountNone && len(result.items) > limit {
+		result.items = result.items[:limit]
+		result.hasMore = true
+	}
+	return result, nil
+}
+
+// seekable reports whether an offset read may seek the page index, which
+// only holds the boundaries of the unfiltered table in ascending key order
+// as it currently is and so can't serve snapshot reads.
+func (p Paginator[T]) seekable(req OffsetRequest, sort []model.SortField) bool {
+	return p.Config.Boundaries != nil && len(req.Filters) == 0 && !req.Snapshot && req.Session == "" &&
+		len(sort) == 1 && sort[0].Column == p.Resource.Key && !sort[0].Desc
+}
+
+// readAfterPrevPage reads a page as the keyset page after the last row of
+// the previous page. The keys of that row come from the After cursor when
+// it was issued for the previous page in the same sort and are looked up
+// with an index only skip otherwise, such as when a client jumps pages.
+// That skip still costs the depth of the page unless the read is seekable,
+// then it starts at the page index boundary before the row.
+func (p Paginator[T]) readAfterPrevPage(ctx context.Context, req OffsetRequest, page int) (offsetRead[T], error) {
+	var result offsetRead[T]
+	limit := req.Limit
+
+	sort, _, countMode, err := p.offsetSettings(req)
+	if err != nil {
+		return result, err
+	}
+	result.countMode = countMode
+
+	var keys []string
+	if req.After != "" {
+		cursor, err := p.Codec.Decode(req.After)
+		if err != nil {
+			return result, &ParamError{Param: "after", Err: err}
+		}
+		if cursor.Resource != p.Resource.Table {
+			return result, &ParamError{Param: "after", Err: ErrInvalidCursor}
+		}
+		if cursor.Page == page-1 && cursor.Sort == FormatSort(sort) && len(cursor.Keys) == len(sort) {
+			keys = cursor.Keys
+		}
+	}
+
+	result.session, err = p.read(ctx, req.Snapshot, req.Session, func(ctx context.Context) error {
+		var err error
+		if keys == nil {
+			query := model.OffsetQuery{Offset: (page-1)*limit - 1, Sort: sort, Filters: req.Filters}
+			if p.seekable(req, sort) {
+				var ok bool
+				query.From, query.Offset, result.indexedAt, ok = p.Config.Boundaries.Seek(query.Offset)
+				if !ok {
+					query.From, query.Offset = "", (page-1)*limit-1
+				}
+			}
+			keys, err = p.Repo.SortKeysAt(ctx, query)
+			if err != nil {
+				return err
+			}
+		}
+
+		// no previous row, the page is past the end
+		if keys != nil {
+			result.items, result.hasMore, err = p.Repo.CursorBasedRead(ctx, model.KeysetQuery{
+				Sort:    sort,
+				Keys:    keys,
+				Filters: req.Filters,
+				Limit:   limit,
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		switch countMode {
+		case CountExact:
+			result.total, err = p.Repo.Total(ctx, req.Filters)
+		case CountEstimated:
+			result.total, err = p.Repo.Estimated(ctx, req.Filters)
+		}
+		return err
+	})
+	return result, err
+}
+
+// checkLimit rejects the page sizes no page can have, requests parsed by a
+// policy never have one.
+func checkLimit(limit int) error {
+	if limit < 1 {
+		return &ParamError{Param: "limit", Err: ErrLimitOutOfRange}
+	}
+	return nil
+}
+
+// read runs read in the snapshot session of the request, if any, under the
+// query budget of the context.
+func (p Paginator[T]) read(ctx context.Context, snapshot bool, token string, read func(ctx context.Context) error) (*model.SnapshotSession, error) {
+	return snapshotRead(ctx, p.Config.Sessions, snapshot, token, func(ctx context.Context) error {
+		return p.Repo.Budgeted(ctx, read)
+	})
+}
+
+// countMode resolves the count strategy of a request, falling back to the configured one.
+func (p Paginator[T]) countMode(mode string) (string, error) {
+	if mode == "" {
+		mode = p.Config.CountMode
+	}
+
+	switch mode {
+	case "":
+		return CountExact, nil
+	case CountExact, CountEstimated, CountNone:
+		return mode, nil
+	default:
+		return "", ErrInvalidCountMode
+	}
+}
+
+// readMode resolves the read mode of a request, falling back to the configured one.
+func (p Paginator[T]) readMode(mode string) (string, error) {
+	if mode == "" {
+		mode = p.Config.Mode
+	}
+
+	switch mode {
+	case "":
+		return OffsetModePlain, nil
+	case OffsetModePlain, OffsetModeDeferred, OffsetModeAdaptive:
+		return mode, nil
+	default:
+		return "", ErrInvalidMode
+	}
+}
+
+// adaptiveThreshold returns the depth past which adaptive reads use keysets.
+func (p Paginator[T]) adaptiveThreshold() int {
+	if p.Config.AdaptiveThreshold > 0 {
+		return p.Config.AdaptiveThreshold
+	}
+	return DefaultAdaptiveThreshold
+}
+
+// encodeCursor issues the cursor token pointing at the given item.
+func (p Paginator[T]) encodeCursor(item T, sort []model.SortField) (string, error) {
+	return p.Codec.Encode(p.cursor(item, sort))
+}
+
+// cursor returns the cursor pointing at the given item.
+func (p Paginator[T]) cursor(item T, sort []model.SortField) model.Cursor {
+	direction := model.SortAsc
+	if sort[0].Desc {
+		direction = model.SortDesc
+	}
+
+	keys := make([]string, 0, len(sort))
+	for _, field := range sort {
+		keys = append(keys, p.Resource.SortKey(item, field.Column))
+	}
+
+	return model.Cursor{
+		Resource:  p.Resource.Table,
+		Direction: direction,
+		Sort:      FormatSort(sort),
+		Keys:      keys,
+	}
+}
+
+// decodeCursor resolves the sort of the request and the keys the repository
+// seeks from. An empty token maps to the initial cursor, which has no keys
+// and is read in the descending key order unless the request sets a sort.
+func (p Paginator[T]) decodeCursor(cursorToken, rawSort string) ([]model.SortField, []string, error) {
+	if cursorToken == "" {
+		if rawSort == "" {
+			rawSort = "-" + p.Resource.Key
+		}
+		sort, err := parseSort(rawSort, p.Resource.SortColumns, p.Resource.Key)
+		return sort, nil, err
+	}
+
+	cursor, err := p.Codec.Decode(cursorToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	// resources share the cursor secret, keys only mean something in their own table
+	if cursor.Resource != p.Resource.Table {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	sort, err := parseSort(cursor.Sort, p.Resource.SortColumns, p.Resource.Key)
+	if err != nil || len(cursor.Keys) != len(sort) {
+		return nil, nil, ErrInvalidCursor
+	}
+
+	if rawSort != "" {
+		reqSort, err := parseSort(rawSort, p.Resource.SortColumns, p.Resource.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		// a cursor only points into the order it was issued for
+		if FormatSort(reqSort) != cursor.Sort {
+			return nil, nil, ErrInvalidCursor
+		}
+	}
+
+	return sort, cursor.Keys, nil
+}
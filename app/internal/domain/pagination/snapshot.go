@@ -0,0 +1,37 @@
+package pagination
+
+import (
+	"context"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+)
+
+// snapshotRead runs read inside the snapshot session of a request. A request
+// with a session token reads from that session, one asking for a snapshot
+// without a token opens a new session first and any other request reads the
+// live table. The session read from is returned, nil when there is none.
+func snapshotRead(ctx context.Context, sessions *repo.SnapshotSessions, snapshot bool, token string, read func(ctx context.Context) error) (*model.SnapshotSession, error) {
+	if !snapshot && token == "" {
+		return nil, read(ctx)
+	}
+
+	opened := false
+	if token == "" {
+		session, err := sessions.Open(ctx)
+		if err != nil {
+			return nil, err
+		}
+		token, opened = session.Token, true
+	}
+
+	session, err := sessions.Read(ctx, token, read)
+	if err != nil {
+		// nobody holds the token of a session whose first page failed
+		if opened {
+			sessions.Close(token)
+		}
+		return nil, err
+	}
+	return &session, nil
+}
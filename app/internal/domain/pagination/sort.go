@@ -0,0 +1,60 @@
+package pagination
+
+import (
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// tieBreaker is the unique users column appended to every sort so the order is stable.
+const tieBreaker = "id"
+
+// ErrInvalidSort is returned when a sort param names an unknown or repeated column.
+var ErrInvalidSort = errors.New("invalid sort")
+
+// ParseSort parses a comma separated sort param such as "surname,name,-id"
+// where a leading "-" sorts the column descending. Every column must be one
+// of the allowed columns and the id tie breaker is appended, in the direction
+// of the last column, when it is missing.
+func ParseSort(raw string, allowed []string) ([]model.SortField, error) {
+	return parseSort(raw, allowed, tieBreaker)
+}
+
+// parseSort parses a sort param like ParseSort, appending the key column of
+// the resource instead of the id tie breaker.
+func parseSort(raw string, allowed []string, key string) ([]model.SortField, error) {
+	var sort []model.SortField
+	seen := map[string]bool{}
+
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		field := model.SortField{Column: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+
+		if !slices.Contains(allowed, field.Column) || seen[field.Column] {
+			return nil, ErrInvalidSort
+		}
+
+		seen[field.Column] = true
+		sort = append(sort, field)
+	}
+
+	if !seen[key] {
+		sort = append(sort, model.SortField{Column: key, Desc: sort[len(sort)-1].Desc})
+	}
+	return sort, nil
+}
+
+// FormatSort renders a sort back into its canonical param form.
+func FormatSort(sort []model.SortField) string {
+	parts := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Desc {
+			parts = append(parts, "-"+field.Column)
+			continue
+		}
+		parts = append(parts, field.Column)
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,298 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// names of the registered users strategies
+const (
+	StrategyOffset = "offset"
+	StrategyKeyset = "keyset"
+	StrategyRange  = "range"
+)
+
+// rangeUnit is the range unit of users item ranges.
+const rangeUnit = "items"
+
+// ErrInvalidRange is returned for Range headers that can't be parsed.
+var ErrInvalidRange = errors.New("invalid range")
+
+// position params of the registered strategies
+var (
+	offsetPositionParams = []string{"page", "after"}
+	keysetPositionParams = []string{"after", "before", "cursor"}
+	rangePositionParams  = []string{"offset"}
+)
+
+// NewResourceRegistry registers every pagination strategy of the resource
+// read by the paginator, page numbered limit-offset pages being the
+// fallback. New strategies are registered here.
+func NewResourceRegistry[T any](p Paginator[T]) *Registry[T] {
+	registry := NewRegistry[T](StrategyOffset)
+	registry.Register(Strategy[T]{
+		Name:           StrategyOffset,
+		Paginate:       p.paginateOffset,
+		Parse:          p.parseOffsetParams,
+		PositionParams: offsetPositionParams,
+	})
+	registry.Register(Strategy[T]{
+		Name:           StrategyKeyset,
+		Paginate:       p.paginateKeyset,
+		Parse:          p.parseKeysetParams,
+		PositionParams: keysetPositionParams,
+	})
+	registry.Register(Strategy[T]{
+		Name:           StrategyRange,
+		Paginate:       p.paginateRange,
+		Parse:          p.parseRangeParams,
+		PositionParams: rangePositionParams,
+	})
+	return registry
+}
+
+// parseOffsetParams reads page numbered requests, the position is the page
+// bounded by the policy and adaptive reads continue from the after cursor.
+func (p Paginator[T]) parseOffsetParams(params url.Values, _ http.Header) (PageRequest, error) {
+	req, err := p.parsePageParams(params, offsetPositionParams)
+	if err != nil {
+		return req, err
+	}
+
+	req.After = params.Get("after")
+	if pageStr := params.Get("page"); pageStr != "" {
+		page, adjusted, err := p.Config.Policy.ParsePage(pageStr, req.Limit)
+		if err != nil {
+			return req, err
+		}
+		req.Position = strconv.Itoa(page)
+		req.adjust(adjusted)
+	}
+	return req, nil
+}
+
+func (p Paginator[T]) paginateOffset(ctx context.Context, req PageRequest) (Page[T], error) {
+	var page Page[T]
+
+	pageNum := 1
+	if req.Position != "" {
+		var err error
+		if pageNum, err = strconv.Atoi(req.Position); err != nil {
+			return page, &ParamError{Param: "page", Err: ErrInvalidPosition}
+		}
+	}
+
+	data, err := p.Offset(ctx, OffsetRequest{
+		Page:     pageNum,
+		Limit:    req.Limit,
+		Sort:     req.Sort,
+		Filters:  req.Filters,
+		Mode:     req.Mode,
+		Count:    req.Count,
+		After:    req.After,
+		Snapshot: req.Snapshot,
+		Session:  req.Session,
+	})
+	if err != nil {
+		return page, err
+	}
+
+	pg := data.Pagination
+	offset := (pg.CurrentPage - 1) * req.Limit
+	page.Items = data.Items
+	page.Offset = &offset
+	page.Session = data.Session
+	page.ServedBy = pg.ServedBy
+	page.IndexAge = pg.IndexAge
+
+	if pg.CurrentPage > 1 {
+		page.Prev = map[string]string{"page": strconv.Itoa(pg.CurrentPage - 1)}
+	}
+	if pg.NextPage > pg.CurrentPage {
+		page.Next = map[string]string{"page": strconv.Itoa(pg.NextPage)}
+		if pg.NextCursor != nil {
+			page.Next["after"] = *pg.NextCursor
+		}
+	}
+	if pg.CountMode != CountNone && pg.TotalPages > 0 {
+		page.Last = map[string]string{"page": strconv.Itoa(pg.TotalPages)}
+	}
+	return page, nil
+}
+
+// parseKeysetParams reads cursor requests, the position is the cursor token.
+func (p Paginator[T]) parseKeysetParams(params url.Values, _ http.Header) (PageRequest, error) {
+	req, err := p.parsePageParams(params, keysetPositionParams)
+	if err != nil {
+		return req, err
+	}
+
+	after, before := params.Get("after"), params.Get("before")
+	// `cursor` is kept as an alias of `after`
+	if after == "" {
+		after = params.Get("cursor")
+	}
+	if after != "" && before != "" {
+		return req, &ParamError{Param: "before", Err: ErrInvalidCursor}
+	}
+
+	req.Position, req.Backward = after, before != ""
+	if req.Backward {
+		req.Position = before
+	}
+	return req, nil
+}
+
+func (p Paginator[T]) paginateKeyset(ctx context.Context, req PageRequest) (Page[T], error) {
+	var page Page[T]
+
+	cursorReq := CursorRequest{
+		Sort:     req.Sort,
+		Filters:  req.Filters,
+		Limit:    req.Limit,
+		Snapshot: req.Snapshot,
+		Session:  req.Session,
+	}
+	if req.Backward {
+		cursorReq.Before = req.Position
+	} else {
+		cursorReq.After = req.Position
+	}
+
+	data, err := p.Keyset(ctx, cursorReq)
+	if err != nil {
+		return page, err
+	}
+
+	page.Items = data.Items
+	page.Session = data.Session
+	if data.NextCursor != nil {
+		page.Next = map[string]string{"after": *data.NextCursor}
+	}
+	if data.PrevCursor != nil {
+		page.Prev = map[string]string{"before": *data.PrevCursor}
+	}
+	return page, nil
+}
+
+// parseRangeParams reads item range requests, the position is the zero based
+// offset of the first item. Ranges come from a `Range: items=0-24` header or
+// from the offset and limit params, both bounded by the policy.
+func (p Paginator[T]) parseRangeParams(params url.Values, header http.Header) (PageRequest, error) {
+	req, err := p.parsePageParams(params, rangePositionParams)
+	if err != nil {
+		return req, err
+	}
+
+	policy := p.Config.Policy
+	rangeHeader := header.Get("Range")
+	if rangeHeader == "" {
+		if offsetStr := params.Get("offset"); offsetStr != "" {
+			offset, adjusted, err := policy.ParseOffset(offsetStr)
+			if err != nil {
+				return req, err
+			}
+			req.Position = strconv.Itoa(offset)
+			req.adjust(adjusted)
+		}
+		return req, nil
+	}
+
+	offset, limit, err := parseItemsRange(rangeHeader, req.Limit)
+	if err != nil {
+		return req, err
+	}
+
+	offset, adjustedOffset, err := policy.Offset(offset)
+	if err != nil {
+		return req, err
+	}
+	limit, adjustedLimit, err := policy.Limit("limit", limit)
+	if err != nil {
+		return req, err
+	}
+	req.Position, req.Limit = strconv.Itoa(offset), limit
+	req.adjust(adjustedOffset)
+	req.adjust(adjustedLimit)
+	return req, nil
+}
+
+func (p Paginator[T]) paginateRange(ctx context.Context, req PageRequest) (Page[T], error) {
+	var page Page[T]
+
+	offset := 0
+	if req.Position != "" {
+		var err error
+		if offset, err = strconv.Atoi(req.Position); err != nil || offset < 0 {
+			return page, &ParamError{Param: "offset", Err: ErrInvalidPosition}
+		}
+	}
+
+	data, err := p.Range(ctx, OffsetRequest{
+		Offset:   offset,
+		Limit:    req.Limit,
+		Sort:     req.Sort,
+		Filters:  req.Filters,
+		Mode:     req.Mode,
+		Count:    req.Count,
+		Snapshot: req.Snapshot,
+		Session:  req.Session,
+	})
+	// an unsatisfiable range still reports the total
+	page.Total = data.Total
+	if err != nil {
+		return page, err
+	}
+
+	page.Items = data.Items
+	page.Offset = &data.First
+	page.Session = data.Session
+
+	rangeParams := func(offset int) map[string]string {
+		return map[string]string{"offset": strconv.Itoa(offset), "limit": strconv.Itoa(req.Limit)}
+	}
+	if data.First > 0 {
+		page.Prev = rangeParams(max(0, data.First-req.Limit))
+	}
+	// an uncounted full range may be followed by an empty one
+	if (data.Total != nil && data.Last+1 < *data.Total) || (data.Total == nil && len(data.Items) == req.Limit) {
+		page.Next = rangeParams(data.Last + 1)
+	}
+	if data.Total != nil && *data.Total > 0 && req.Limit > 0 {
+		page.Last = rangeParams((*data.Total - 1) / req.Limit * req.Limit)
+	}
+	return page, nil
+}
+
+// parseItemsRange parses a single "items=first-last" range into its offset
+// and limit, an open ended range such as "items=50-" takes the default limit.
+func parseItemsRange(header string, defaultLimit int) (int, int, error) {
+	unit, spec, found := strings.Cut(strings.TrimSpace(header), "=")
+	if !found || unit != rangeUnit || strings.Contains(spec, ",") {
+		return 0, 0, ErrInvalidRange
+	}
+
+	firstStr, lastStr, found := strings.Cut(spec, "-")
+	if !found {
+		return 0, 0, ErrInvalidRange
+	}
+
+	first, err := strconv.Atoi(strings.TrimSpace(firstStr))
+	if err != nil || first < 0 {
+		return 0, 0, ErrInvalidRange
+	}
+
+	if strings.TrimSpace(lastStr) == "" {
+		return first, defaultLimit, nil
+	}
+
+	last, err := strconv.Atoi(strings.TrimSpace(lastStr))
+	if err != nil || last < first {
+		return 0, 0, ErrInvalidRange
+	}
+	return first, last - first + 1, nil
+}
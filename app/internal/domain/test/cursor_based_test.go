@@ -2,7 +2,10 @@ package test
 
 import (
 	"context"
+	"errors"
 	"log"
+	"reflect"
+	"strconv"
 	"testing"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/domain"
@@ -22,8 +25,7 @@ func TestCursorBasedRead(t *testing.T) {
 
 	ctx := context.Background()
 	testContainer := dbAttributes.PgTestContainerSetup(ctx)
-	schemaFile := "../../../../pkg/schema.sql"
-	db := dbAttributes.DbSetup(ctx, testContainer, schemaFile)
+	db := dbAttributes.DbSetup(ctx, testContainer)
 	defer pkg.TearDown(db, testContainer)
 
 	repoInterface := repo.RepositoryHandler{Db: db}
@@ -36,8 +38,37 @@ func TestCursorBasedRead(t *testing.T) {
 		log.Fatalf("Failed to load test data with error: %v", err)
 	}
 
-	repoHandler := repo.RepositoryHandler{Db: db}
-	handler := pagination.CursorBasedHandler{Repo: repoHandler}
+	handler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{CursorSecret: "test-secret"})
+
+	encodeCursor := func(t testing.TB, id int) string {
+		t.Helper()
+		token, err := handler.Users.Codec.Encode(model.Cursor{
+			Resource:  "users",
+			Direction: model.SortDesc,
+			Sort:      "-id",
+			Keys:      []string{strconv.Itoa(id)},
+		})
+		if err != nil {
+			t.Fatalf("cursor encoding failed with error: %v", err)
+		}
+		return token
+	}
+
+	decodeCursor := func(t testing.TB, token *string) int {
+		t.Helper()
+		if token == nil {
+			return 0
+		}
+		cursor, err := handler.Users.Codec.Decode(*token)
+		if err != nil {
+			t.Fatalf("cursor decoding failed with error: %v", err)
+		}
+		id, err := strconv.Atoi(cursor.Keys[0])
+		if err != nil {
+			t.Fatalf("cursor key is not an id: %v", err)
+		}
+		return id
+	}
 
 	t.Run("pagination data", func(t *testing.T) {
 
@@ -46,58 +77,178 @@ func TestCursorBasedRead(t *testing.T) {
 			cursor    int
 			limit     int
 			isSuccess bool
-			expected  model.UsersCursorBasedMetaData
+			expected  int
 		}{
 			{
-				name:      "success - next cursor (10)",
+				name:      "success - last page has no next cursor",
 				cursor:    10,
 				limit:     10,
 				isSuccess: true,
-				expected: model.UsersCursorBasedMetaData{
-					Users:      model.UsersData{},
-					NextCursor: 1,
-				},
+				expected:  0,
 			},
 			{
 				name:      "success - next cursor (10)",
 				cursor:    20,
 				limit:     10,
 				isSuccess: true,
-				expected: model.UsersCursorBasedMetaData{
-					Users:      model.UsersData{},
-					NextCursor: 10,
-				},
+				expected:  10,
 			},
 			{
 				name:      "success - next cursor (90)",
 				cursor:    100,
 				limit:     10,
 				isSuccess: true,
-				expected: model.UsersCursorBasedMetaData{
-					Users:      model.UsersData{},
-					NextCursor: 90,
-				},
+				expected:  90,
 			},
 		}
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
 
-				got, err := handler.Retrieve(ctx, tc.cursor, tc.limit)
+				got, err := handler.Retrieve(ctx, pagination.CursorRequest{After: encodeCursor(t, tc.cursor), Limit: tc.limit})
 				if err != nil {
-					t.Errorf("expected error nil, got %v", err)
+					t.Fatalf("expected error nil, got %v", err)
 				}
 
+				nextCursor := decodeCursor(t, got.NextCursor)
 				if tc.isSuccess {
-					if tc.expected.NextCursor != got.NextCursor {
-						t.Errorf("expected next cursor: %v, got %v", tc.expected.NextCursor, got.NextCursor)
+					if tc.expected != nextCursor {
+						t.Errorf("expected next cursor: %v, got %v", tc.expected, nextCursor)
 					}
 				} else {
-					if tc.expected.NextCursor == got.NextCursor {
-						t.Errorf("expected next cursor: %v, got %v to be unequal", tc.expected.NextCursor, got.NextCursor)
+					if tc.expected == nextCursor {
+						t.Errorf("expected next cursor: %v, got %v to be unequal", tc.expected, nextCursor)
+					}
+				}
+			})
+		}
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		forged := pagination.NewCursorCodec("another-secret")
+		token, err := forged.Encode(model.Cursor{Resource: "users", Direction: model.SortDesc, Sort: "-id", Keys: []string{"10"}})
+		if err != nil {
+			t.Fatalf("cursor encoding failed with error: %v", err)
+		}
+
+		if _, err := handler.Retrieve(ctx, pagination.CursorRequest{After: token, Limit: 10}); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidCursor, err)
+		}
+	})
+
+	t.Run("past the last row", func(t *testing.T) {
+		got, err := handler.Retrieve(ctx, pagination.CursorRequest{After: encodeCursor(t, 1), Limit: 10})
+		if err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+
+		if got.Users == nil || len(got.Users) != 0 {
+			t.Errorf("expected an empty users page, got %v", got.Users)
+		}
+		if got.HasMore || got.NextCursor != nil || got.PrevCursor != nil {
+			t.Errorf("expected an exhausted page, got %+v", got)
+		}
+	})
+
+	t.Run("backward navigation", func(t *testing.T) {
+		first, err := handler.Retrieve(ctx, pagination.CursorRequest{Limit: 10})
+		if err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+		if first.PrevCursor != nil {
+			t.Errorf("expected no prev cursor on the first page, got %v", first.PrevCursor)
+		}
+
+		second, err := handler.Retrieve(ctx, pagination.CursorRequest{After: *first.NextCursor, Limit: 10})
+		if err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+
+		back, err := handler.Retrieve(ctx, pagination.CursorRequest{Before: *second.PrevCursor, Limit: 10})
+		if err != nil {
+			t.Fatalf("expected error nil, got %v", err)
+		}
+
+		if !reflect.DeepEqual(first.Users, back.Users) {
+			t.Errorf("expected users: %v, got %v", first.Users, back.Users)
+		}
+	})
+
+	t.Run("after and before", func(t *testing.T) {
+		token := encodeCursor(t, 50)
+		if _, err := handler.Retrieve(ctx, pagination.CursorRequest{After: token, Before: token, Limit: 10}); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidCursor, err)
+		}
+	})
+
+	t.Run("multi column sort", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			sort    string
+			orderBy string
+		}{
+			{
+				name:    "uniform direction",
+				sort:    "surname,name",
+				orderBy: "surname ASC, name ASC, id ASC",
+			},
+			{
+				name:    "mixed direction",
+				sort:    "surname,name,-id",
+				orderBy: "surname ASC, name ASC, id DESC",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				var expected []int
+				rows, err := db.Query("SELECT id FROM users ORDER BY " + tc.orderBy)
+				if err != nil {
+					t.Fatalf("query exec failed with error: %v", err)
+				}
+				defer rows.Close()
+				for rows.Next() {
+					var id int
+					if err := rows.Scan(&id); err != nil {
+						t.Fatalf("query scan failed with error: %v", err)
+					}
+					expected = append(expected, id)
+				}
+
+				// walk every page through the next cursor
+				var got []int
+				req := pagination.CursorRequest{Sort: tc.sort, Limit: 7}
+				for {
+					page, err := handler.Retrieve(ctx, req)
+					if err != nil {
+						t.Fatalf("expected error nil, got %v", err)
+					}
+					for _, user := range page.Users {
+						got = append(got, user.ID)
+					}
+					if page.NextCursor == nil {
+						break
 					}
+					req.After = *page.NextCursor
+				}
+
+				if !reflect.DeepEqual(expected, got) {
+					t.Errorf("expected ids: %v, got %v", expected, got)
 				}
 			})
 		}
 	})
+
+	t.Run("invalid sort", func(t *testing.T) {
+		if _, err := handler.Retrieve(ctx, pagination.CursorRequest{Sort: "password", Limit: 10}); !errors.Is(err, pagination.ErrInvalidSort) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidSort, err)
+		}
+	})
+
+	t.Run("cursor from another sort", func(t *testing.T) {
+		req := pagination.CursorRequest{After: encodeCursor(t, 50), Sort: "surname", Limit: 10}
+		if _, err := handler.Retrieve(ctx, req); !errors.Is(err, pagination.ErrInvalidCursor) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidCursor, err)
+		}
+	})
 }
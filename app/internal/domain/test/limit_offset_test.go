@@ -2,9 +2,13 @@ package test
 
 import (
 	"context"
+	"errors"
 	"log"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/domain"
 	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
@@ -23,8 +27,7 @@ func TestLimitOffSetHandler(t *testing.T) {
 
 	ctx := context.Background()
 	testContainer := dbAttributes.PgTestContainerSetup(ctx)
-	schemaFile := "../../../../pkg/schema.sql"
-	db := dbAttributes.DbSetup(ctx, testContainer, schemaFile)
+	db := dbAttributes.DbSetup(ctx, testContainer)
 	defer pkg.TearDown(db, testContainer)
 
 	repoInterface := repo.RepositoryHandler{Db: db}
@@ -37,8 +40,8 @@ func TestLimitOffSetHandler(t *testing.T) {
 		log.Fatalf("Failed to load test data with error: %v", err)
 	}
 
-	repoHandler := repo.RepositoryHandler{Db: db}
-	handler := pagination.LimitOffSetHandler{Repo: repoHandler}
+	handler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{})
+	deferredHandler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{Mode: pagination.OffsetModeDeferred})
 
 	t.Run("pagination data", func(t *testing.T) {
 		const (
@@ -113,7 +116,7 @@ func TestLimitOffSetHandler(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				got, err := handler.RetrieveUsers(ctx, tc.page, tc.limit)
+				got, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: tc.page, Limit: tc.limit})
 				if err != nil {
 					t.Errorf("expected no error, got %v", err)
 				}
@@ -194,7 +197,7 @@ func TestLimitOffSetHandler(t *testing.T) {
 
 		for _, tc := range testCases {
 			t.Run(tc.name, func(t *testing.T) {
-				data, err := handler.RetrieveUsers(ctx, tc.page, tc.limit)
+				data, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: tc.page, Limit: tc.limit})
 
 				if err != nil {
 					t.Errorf("expected no error, got %v", err)
@@ -206,4 +209,226 @@ func TestLimitOffSetHandler(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("sorted and filtered", func(t *testing.T) {
+		var surname string
+		if err := db.QueryRow("SELECT surname FROM users ORDER BY id LIMIT 1").Scan(&surname); err != nil {
+			t.Fatalf("query exec failed with error: %v", err)
+		}
+		prefix := surname[:1]
+
+		var count int
+		if err := db.QueryRow("SELECT COUNT(id) FROM users WHERE surname LIKE $1", prefix+"%").Scan(&count); err != nil {
+			t.Fatalf("query exec failed with error: %v", err)
+		}
+
+		limit := 2
+		got, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{
+			Page:    1,
+			Limit:   limit,
+			Sort:    "-surname",
+			Filters: []model.Filter{{Column: "surname", Op: model.FilterPrefix, Value: prefix}},
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expectedPages := int(math.Ceil(float64(count) / float64(limit)))
+		if got.Pagination.TotalPages != expectedPages {
+			t.Errorf("expected total pages: %v, got %v", expectedPages, got.Pagination.TotalPages)
+		}
+
+		rows, err := db.Query("SELECT id FROM users WHERE surname LIKE $1 ORDER BY surname DESC, id DESC LIMIT $2", prefix+"%", limit)
+		if err != nil {
+			t.Fatalf("query exec failed with error: %v", err)
+		}
+		defer rows.Close()
+
+		var expectedIDs, gotIDs []int
+		for rows.Next() {
+			var id int
+			if err := rows.Scan(&id); err != nil {
+				t.Fatalf("query scan failed with error: %v", err)
+			}
+			expectedIDs = append(expectedIDs, id)
+		}
+		for _, user := range got.Users {
+			if !strings.HasPrefix(user.Surname, prefix) {
+				t.Errorf("expected surname with prefix %v, got %v", prefix, user.Surname)
+			}
+			gotIDs = append(gotIDs, user.ID)
+		}
+
+		if !reflect.DeepEqual(expectedIDs, gotIDs) {
+			t.Errorf("expected ids: %v, got %v", expectedIDs, gotIDs)
+		}
+	})
+
+	t.Run("deferred join", func(t *testing.T) {
+		for _, sort := range []string{"id", "-surname,name"} {
+			req := pagination.OffsetRequest{Page: 4, Limit: 10, Sort: sort}
+			expected, err := handler.RetrieveUsers(ctx, req)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			// configured default
+			got, err := deferredHandler.RetrieveUsers(ctx, req)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected data: %v, got %v", expected, got)
+			}
+
+			// per request
+			req.Mode = pagination.OffsetModeDeferred
+			got, err = handler.RetrieveUsers(ctx, req)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(expected, got) {
+				t.Errorf("expected data: %v, got %v", expected, got)
+			}
+		}
+
+		_, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Mode: "index"})
+		if !errors.Is(err, pagination.ErrInvalidMode) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidMode, err)
+		}
+	})
+
+	t.Run("count modes", func(t *testing.T) {
+		t.Run("none", func(t *testing.T) {
+			got, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 3, Limit: 10, Count: pagination.CountNone})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			expected := model.Pagination{CurrentPage: 3, NextPage: 4, PrevPage: 2, CountMode: pagination.CountNone}
+			if !reflect.DeepEqual(expected, got.Pagination) {
+				t.Errorf("expected pagination: %v, got %v", expected, got.Pagination)
+			}
+			if len(got.Users) != 10 {
+				t.Errorf("expected %v users, got %v", 10, len(got.Users))
+			}
+		})
+
+		t.Run("none on the last page", func(t *testing.T) {
+			got, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 10, Limit: 10, Count: pagination.CountNone})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got.Pagination.NextPage != 10 {
+				t.Errorf("expected next page: %v, got %v", 10, got.Pagination.NextPage)
+			}
+		})
+
+		t.Run("estimated", func(t *testing.T) {
+			if _, err := db.Exec("ANALYZE users"); err != nil {
+				t.Fatalf("analyze failed with error: %v", err)
+			}
+
+			got, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Count: pagination.CountEstimated})
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got.Pagination.CountMode != pagination.CountEstimated {
+				t.Errorf("expected count mode: %v, got %v", pagination.CountEstimated, got.Pagination.CountMode)
+			}
+			if got.Pagination.TotalPages != 10 {
+				t.Errorf("expected total pages: %v, got %v", 10, got.Pagination.TotalPages)
+			}
+		})
+
+		t.Run("invalid", func(t *testing.T) {
+			_, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Count: "approximate"})
+			if !errors.Is(err, pagination.ErrInvalidCountMode) {
+				t.Errorf("expected error: %v, got %v", pagination.ErrInvalidCountMode, err)
+			}
+		})
+	})
+
+	t.Run("invalid sort and filter", func(t *testing.T) {
+		_, err := handler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Sort: "password"})
+		if !errors.Is(err, pagination.ErrInvalidSort) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidSort, err)
+		}
+
+		_, err = handler.RetrieveUsers(ctx, pagination.OffsetRequest{
+			Page:    1,
+			Limit:   10,
+			Filters: []model.Filter{{Column: "id", Op: model.FilterEq, Value: "1"}},
+		})
+		if !errors.Is(err, pagination.ErrInvalidFilter) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrInvalidFilter, err)
+		}
+	})
+
+	t.Run("item range", func(t *testing.T) {
+		got, err := handler.RetrieveRange(ctx, pagination.OffsetRequest{Offset: 95, Limit: 10})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		// the range is cut at the last user
+		if got.First != 95 || got.Last != 99 {
+			t.Errorf("expected range: %v-%v, got %v-%v", 95, 99, got.First, got.Last)
+		}
+		if got.Total == nil || *got.Total != 100 {
+			t.Errorf("expected total: %v, got %v", 100, got.Total)
+		}
+		if got.Users[0].ID != 96 {
+			t.Errorf("expected first user id: %v, got %v", 96, got.Users[0].ID)
+		}
+
+		_, err = handler.RetrieveRange(ctx, pagination.OffsetRequest{Offset: 100, Limit: 10})
+		if !errors.Is(err, pagination.ErrRangeNotSatisfiable) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrRangeNotSatisfiable, err)
+		}
+	})
+
+	t.Run("snapshot session", func(t *testing.T) {
+		snapshotHandler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{
+			Sessions: repo.NewSnapshotSessions(db, time.Minute, 1),
+		})
+
+		first, err := snapshotHandler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Snapshot: true})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if first.Session == nil {
+			t.Fatalf("expected a snapshot session")
+		}
+
+		// rows written after the snapshot are not visible to its pages
+		if err := seedHandler.Seed(ctx, 20); err != nil {
+			t.Fatalf("seeding failed with error: %v", err)
+		}
+		defer db.Exec("DELETE FROM users WHERE id > 100")
+
+		last, err := snapshotHandler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 10, Limit: 10, Session: first.Session.Token})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if last.Pagination.TotalPages != 10 {
+			t.Errorf("expected total pages: %v, got %v", 10, last.Pagination.TotalPages)
+		}
+		if last.Users[len(last.Users)-1].ID != 100 {
+			t.Errorf("expected the last user id: %v, got %v", 100, last.Users[len(last.Users)-1].ID)
+		}
+
+		_, err = snapshotHandler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Snapshot: true})
+		if !errors.Is(err, repo.ErrTooManySessions) {
+			t.Errorf("expected error: %v, got %v", repo.ErrTooManySessions, err)
+		}
+
+		_, err = snapshotHandler.RetrieveUsers(ctx, pagination.OffsetRequest{Page: 1, Limit: 10, Session: "unknown"})
+		if !errors.Is(err, repo.ErrSessionNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrSessionNotFound, err)
+		}
+	})
 }
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestManifest(t *testing.T) {
+	writeManifest := func(t testing.TB, name, content string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("manifest write failed with error: %v", err)
+		}
+		return path
+	}
+
+	orders := pagination.ManifestResource{
+		Name:            "orders",
+		Table:           "orders",
+		Key:             "id",
+		Fields:          []string{"id", "status", "created_at"},
+		Sort:            []string{"created_at"},
+		Filters:         []string{"status"},
+		MaxPageSize:     100,
+		DefaultStrategy: pagination.StrategyOffset,
+	}
+
+	t.Run("load", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			file    string
+			content string
+		}{
+			{
+				name: "yaml",
+				file: "resources.yaml",
+				content: `resources:
+  - table: orders
+    fields: [id, status, created_at]
+    sort: [created_at]
+    filters: [status]
+    max_page_size: 100
+`,
+			},
+			{
+				name:    "json",
+				file:    "resources.json",
+				content: `{"resources": [{"table": "orders", "fields": ["id", "status", "created_at"], "sort": ["created_at"], "filters": ["status"], "max_page_size": 100}]}`,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				manifest, err := pagination.LoadManifest(writeManifest(t, tc.file, tc.content))
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				want := []pagination.ManifestResource{orders}
+				if !reflect.DeepEqual(manifest.Resources, want) {
+					t.Errorf("expected resources: %+v, got %+v", want, manifest.Resources)
+				}
+			})
+		}
+	})
+
+	t.Run("invalid manifests", func(t *testing.T) {
+		testCases := []struct {
+			name    string
+			content string
+		}{
+			{name: "unquoted table", content: "resources:\n  - table: orders; drop table users\n    fields: [id]\n"},
+			{name: "hidden key", content: "resources:\n  - table: orders\n    fields: [status]\n"},
+			{name: "hidden sort field", content: "resources:\n  - table: orders\n    fields: [id]\n    sort: [status]\n"},
+			{name: "hidden filter field", content: "resources:\n  - table: orders\n    fields: [id]\n    filters: [status]\n"},
+			{name: "unknown strategy", content: "resources:\n  - table: orders\n    fields: [id]\n    default_strategy: seek\n"},
+			{name: "unknown params mode", content: "resources:\n  - table: orders\n    fields: [id]\n    params: loose\n"},
+			{name: "default page size past the max", content: "resources:\n  - table: orders\n    fields: [id]\n    default_page_size: 50\n    max_page_size: 10\n"},
+			{name: "duplicate name", content: "resources:\n  - table: orders\n    fields: [id]\n  - table: orders\n    fields: [id]\n"},
+			{name: "malformed", content: "resources: [\n"},
+		}
+
+		for _, tc := range testCases {
+			_, err := pagination.LoadManifest(writeManifest(t, "resources.yaml", tc.content))
+			if !errors.Is(err, pagination.ErrInvalidManifest) {
+				t.Errorf("%v: expected error: %v, got %v", tc.name, pagination.ErrInvalidManifest, err)
+			}
+		}
+	})
+
+	t.Run("check", func(t *testing.T) {
+		columnsQuery := "SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position;"
+		indexesQuery := "SELECT a.attname, i.indisunique AND i.indnkeyatts = 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0] WHERE c.relname = $1 AND c.relnamespace = current_schema()::regnamespace;"
+
+		dataTypes := map[string]string{"id": "bigint", "status": "text", "created_at": "timestamp with time zone"}
+
+		testCases := []struct {
+			name     string
+			columns  []string
+			nullable []string
+			indexes  map[string]bool
+			valid    bool
+		}{
+			{name: "valid", columns: []string{"id", "status", "created_at"}, indexes: map[string]bool{"id": true, "created_at": false}, valid: true},
+			{name: "missing table", columns: nil},
+			{name: "missing column", columns: []string{"id", "status"}},
+			{name: "key not unique", columns: []string{"id", "status", "created_at"}, indexes: map[string]bool{"id": false, "created_at": false}},
+			{name: "unindexed sort field", columns: []string{"id", "status", "created_at"}, indexes: map[string]bool{"id": true}},
+			{name: "nullable filter field", columns: []string{"id", "status", "created_at"}, nullable: []string{"status"}, indexes: map[string]bool{"id": true, "created_at": false}, valid: true},
+			{name: "nullable sort field", columns: []string{"id", "status", "created_at"}, nullable: []string{"created_at"}, indexes: map[string]bool{"id": true, "created_at": false}},
+			{name: "nullable key", columns: []string{"id", "status", "created_at"}, nullable: []string{"id"}, indexes: map[string]bool{"id": true, "created_at": false}},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				db, mock, err := pkg.DataDogDbMock()
+				if err != nil {
+					t.Fatalf("db mock failed with error: %v", err)
+				}
+				defer db.Close()
+
+				columnRows := mock.NewRows([]string{"column_name", "data_type", "nullable"})
+				for _, column := range tc.columns {
+					columnRows.AddRow(column, dataTypes[column], slices.Contains(tc.nullable, column))
+				}
+				mock.ExpectQuery(columnsQuery).WithArgs("orders").WillReturnRows(columnRows)
+
+				if len(tc.columns) == 3 {
+					indexRows := mock.NewRows([]string{"attname", "unique"})
+					for column, unique := range tc.indexes {
+						indexRows.AddRow(column, unique)
+					}
+					mock.ExpectQuery(indexesQuery).WithArgs("orders").WillReturnRows(indexRows)
+				}
+
+				manifest := pagination.Manifest{Resources: []pagination.ManifestResource{orders}}
+				err = pagination.CheckManifest(context.Background(), db, &manifest)
+				if tc.valid && err != nil {
+					t.Errorf("expected no error, got %v", err)
+				}
+				if want := map[string]string{"status": "text"}; tc.valid && !reflect.DeepEqual(manifest.Resources[0].FilterTypes, want) {
+					t.Errorf("expected filter types: %v, got %v", want, manifest.Resources[0].FilterTypes)
+				}
+				if !tc.valid && !errors.Is(err, pagination.ErrInvalidManifest) {
+					t.Errorf("expected error: %v, got %v", pagination.ErrInvalidManifest, err)
+				}
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+)
+
+func TestUsersRegistry(t *testing.T) {
+	registry := pagination.NewResourceRegistry(pagination.Paginator[model.UserData]{Resource: repo.UsersResource})
+
+	t.Run("names", func(t *testing.T) {
+		want := []string{pagination.StrategyKeyset, pagination.StrategyOffset, pagination.StrategyRange}
+		if got := registry.Names(); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected names: %v, got %v", want, got)
+		}
+	})
+
+	t.Run("lookup", func(t *testing.T) {
+		strategy, err := registry.Lookup("")
+		if err != nil || strategy.Name != pagination.StrategyOffset {
+			t.Errorf("expected the fallback strategy: %v, got %v (%v)", pagination.StrategyOffset, strategy.Name, err)
+		}
+
+		_, err = registry.Lookup("seek")
+		if !errors.Is(err, pagination.ErrUnknownStrategy) {
+			t.Errorf("expected error: %v, got %v", pagination.ErrUnknownStrategy, err)
+		}
+	})
+
+	t.Run("parsers", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			strategy string
+			query    string
+			header   http.Header
+			want     pagination.PageRequest
+			err      error
+		}{
+			{
+				name:     "offset",
+				strategy: pagination.StrategyOffset,
+				query:    "page=3&limit=10&sort=-name&name_prefix=Ja&count=none",
+				want: pagination.PageRequest{
+					Position: "3",
+					Limit:    10,
+					Sort:     "-name",
+					Filters:  []model.Filter{{Column: "name", Op: model.FilterPrefix, Value: "Ja"}},
+					Count:    pagination.CountNone,
+				},
+			},
+			{
+				name:     "keyset backward",
+				strategy: pagination.StrategyKeyset,
+				query:    "before=token",
+				want:     pagination.PageRequest{Position: "token", Backward: true, Limit: pagination.DefaultPageLimit},
+			},
+			{
+				name:     "keyset cursor alias",
+				strategy: pagination.StrategyKeyset,
+				query:    "cursor=token&limit=5",
+				want:     pagination.PageRequest{Position: "token", Limit: 5},
+			},
+			{
+				name:     "keyset both directions",
+				strategy: pagination.StrategyKeyset,
+				query:    "after=a&before=b",
+				err:      pagination.ErrInvalidCursor,
+			},
+			{
+				name:     "range header",
+				strategy: pagination.StrategyRange,
+				header:   http.Header{"Range": []string{"items=50-74"}},
+				want:     pagination.PageRequest{Position: "50", Limit: 25},
+			},
+			{
+				name:     "open ended range header",
+				strategy: pagination.StrategyRange,
+				query:    "limit=10",
+				header:   http.Header{"Range": []string{"items=50-"}},
+				want:     pagination.PageRequest{Position: "50", Limit: 10},
+			},
+			{
+				name:     "range params",
+				strategy: pagination.StrategyRange,
+				query:    "offset=50&limit=25",
+				want:     pagination.PageRequest{Position: "50", Limit: 25},
+			},
+			{
+				name:     "invalid range header",
+				strategy: pagination.StrategyRange,
+				header:   http.Header{"Range": []string{"bytes=0-24"}},
+				err:      pagination.ErrInvalidRange,
+			},
+			{
+				name:     "invalid limit",
+				strategy: pagination.StrategyOffset,
+				query:    "limit=ten",
+				err:      pagination.ErrInvalidLimit,
+			},
+			{
+				name:     "invalid snapshot",
+				strategy: pagination.StrategyKeyset,
+				query:    "snapshot=maybe",
+				err:      pagination.ErrInvalidSnapshot,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				strategy, err := registry.Lookup(tc.strategy)
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+
+				params, err := url.ParseQuery(tc.query)
+				if err != nil {
+					t.Fatalf("query parsing failed with error: %v", err)
+				}
+				if tc.header == nil {
+					tc.header = http.Header{}
+				}
+
+				got, err := strategy.Parse(params, tc.header)
+				if tc.err != nil {
+					if !errors.Is(err, tc.err) {
+						t.Errorf("expected error: %v, got %v", tc.err, err)
+					}
+					return
+				}
+
+				if err != nil {
+					t.Fatalf("expected no error, got %v", err)
+				}
+				if !reflect.DeepEqual(got, tc.want) {
+					t.Errorf("expected request: %+v, got %+v", tc.want, got)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+)
+
+func TestPolicy(t *testing.T) {
+	t.Run("parse", func(t *testing.T) {
+		policy, err := pagination.ParsePolicy(" default_limit=10, max_limit=100,max_depth=5000,mode=strict ")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		want := pagination.Policy{DefaultLimit: 10, MaxLimit: 100, MaxDepth: 5000, Mode: pagination.PolicyStrict}
+		if policy != want {
+			t.Errorf("expected policy: %+v, got %+v", want, policy)
+		}
+
+		for _, spec := range []string{"max_limit", "max_limit=-1", "max_limit=many", "mode=loose", "page_size=10", "default_limit=50,max_limit=10"} {
+			if _, err := pagination.ParsePolicy(spec); !errors.Is(err, pagination.ErrInvalidPolicy) {
+				t.Errorf("%v: expected error: %v, got %v", spec, pagination.ErrInvalidPolicy, err)
+			}
+		}
+	})
+
+	t.Run("override", func(t *testing.T) {
+		base := pagination.Policy{DefaultLimit: 20, MaxLimit: 1000, Mode: pagination.PolicyLenient}
+		got := base.Override(pagination.Policy{MaxLimit: 100, MaxDepth: 5000})
+		want := pagination.Policy{DefaultLimit: 20, MaxLimit: 100, MaxDepth: 5000, Mode: pagination.PolicyLenient}
+		if got != want {
+			t.Errorf("expected policy: %+v, got %+v", want, got)
+		}
+	})
+
+	t.Run("lenient clamps", func(t *testing.T) {
+		policy := pagination.Policy{MaxLimit: 50, MaxDepth: 1000}
+		testCases := []struct {
+			name    string
+			resolve func() (int, *pagination.Adjustment, error)
+			want    int
+			clamped bool
+		}{
+			{name: "default limit", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParseLimit("limit", "") }, want: pagination.DefaultPageLimit},
+			{name: "zero limit", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParseLimit("limit", "0") }, want: 1, clamped: true},
+			{name: "huge limit", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParseLimit("limit", "5000") }, want: 50, clamped: true},
+			{name: "zero page", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParsePage("0", 10) }, want: 1, clamped: true},
+			{name: "deepest page", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParsePage("101", 10) }, want: 101},
+			{name: "page past the max depth", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParsePage("102", 10) }, want: 101, clamped: true},
+			{name: "negative offset", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParseOffset("-5") }, want: 0, clamped: true},
+			{name: "offset past the max depth", resolve: func() (int, *pagination.Adjustment, error) { return policy.ParseOffset("5000") }, want: 1000, clamped: true},
+		}
+
+		for _, tc := range testCases {
+			got, adjusted, err := tc.resolve()
+			if err != nil {
+				t.Fatalf("%v: expected no error, got %v", tc.name, err)
+			}
+			if got != tc.want || (adjusted != nil) != tc.clamped {
+				t.Errorf("%v: expected %v clamped %v, got %v %+v", tc.name, tc.want, tc.clamped, got, adjusted)
+			}
+			if adjusted != nil && adjusted.Applied != got {
+				t.Errorf("%v: expected the applied value %v, got %+v", tc.name, got, adjusted)
+			}
+		}
+	})
+
+	t.Run("strict rejects", func(t *testing.T) {
+		policy := pagination.Policy{MaxLimit: 50, MaxDepth: 1000, Mode: pagination.PolicyStrict}
+		testCases := []struct {
+			name  string
+			err   error
+			param string
+			check func() error
+		}{
+			{name: "invalid limit", err: pagination.ErrInvalidLimit, param: "limit", check: func() error { _, _, err := policy.ParseLimit("limit", "ten"); return err }},
+			{name: "huge limit", err: pagination.ErrLimitOutOfRange, param: "first", check: func() error { _, _, err := policy.Limit("first", 51); return err }},
+			{name: "page past the max depth", err: pagination.ErrOffsetOutOfRange, param: "page", check: func() error { _, _, err := policy.ParsePage("102", 10); return err }},
+			{name: "negative offset", err: pagination.ErrOffsetOutOfRange, param: "offset", check: func() error { _, _, err := policy.Offset(-1); return err }},
+			{name: "unknown param", err: pagination.ErrUnknownParam, param: "pg", check: func() error {
+				return policy.CheckParams(url.Values{"limit": {"10"}, "pg": {"2"}}, []string{"limit", "page"})
+			}},
+		}
+
+		for _, tc := range testCases {
+			err := tc.check()
+			var paramErr *pagination.ParamError
+			if !errors.Is(err, tc.err) || !errors.As(err, &paramErr) || paramErr.Param != tc.param {
+				t.Errorf("%v: expected error: %v of %v, got %v", tc.name, tc.err, tc.param, err)
+			}
+		}
+	})
+}
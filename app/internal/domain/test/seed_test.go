@@ -45,8 +45,7 @@ func TestSeedHandler(t *testing.T) {
 
 	ctx := context.Background()
 	testContainer := dbAttributes.PgTestContainerSetup(ctx)
-	schemaFile := "../../../../pkg/schema.sql"
-	db := dbAttributes.DbSetup(ctx, testContainer, schemaFile)
+	db := dbAttributes.DbSetup(ctx, testContainer)
 	defer pkg.TearDown(db, testContainer)
 
 	repoInterface := repo.RepositoryHandler{Db: db}
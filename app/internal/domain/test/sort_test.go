@@ -0,0 +1,72 @@
+package test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+func TestParseSort(t *testing.T) {
+	allowed := []string{"id", "name", "surname"}
+
+	testCases := []struct {
+		name        string
+		raw         string
+		expected    []model.SortField
+		expectedErr error
+	}{
+		{
+			name:     "tie breaker appended",
+			raw:      "surname,name",
+			expected: []model.SortField{{Column: "surname"}, {Column: "name"}, {Column: "id"}},
+		},
+		{
+			name:     "tie breaker follows last direction",
+			raw:      "-surname",
+			expected: []model.SortField{{Column: "surname", Desc: true}, {Column: "id", Desc: true}},
+		},
+		{
+			name:     "explicit tie breaker",
+			raw:      "surname,name,-id",
+			expected: []model.SortField{{Column: "surname"}, {Column: "name"}, {Column: "id", Desc: true}},
+		},
+		{
+			name:        "unknown column",
+			raw:         "surname,password",
+			expectedErr: pagination.ErrInvalidSort,
+		},
+		{
+			name:        "repeated column",
+			raw:         "name,-name",
+			expectedErr: pagination.ErrInvalidSort,
+		},
+		{
+			name:        "empty column",
+			raw:         "name,",
+			expectedErr: pagination.ErrInvalidSort,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := pagination.ParseSort(tc.raw, allowed)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, got) {
+				t.Errorf("expected sort: %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	t.Run("format", func(t *testing.T) {
+		sort, _ := pagination.ParseSort("surname,name,-id", allowed)
+		if got := pagination.FormatSort(sort); got != "surname,name,-id" {
+			t.Errorf("expected sort: %v, got %v", "surname,name,-id", got)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// MaxCreateUsers is the largest number of users a single create request holds.
+const MaxCreateUsers = 1000
+
+// column widths of the users table
+const (
+	maxNameLen    = 75
+	maxSurnameLen = 200
+)
+
+var (
+	// ErrInvalidUser is returned for users whose fields can't be stored.
+	ErrInvalidUser = errors.New("invalid user")
+	// ErrInvalidUserBatch is returned for create requests without users or
+	// with more than MaxCreateUsers.
+	ErrInvalidUserBatch = errors.New("invalid user batch")
+)
+
+// UserError is returned for a user field that can't be stored. Field names
+// the field and Err is ErrInvalidUser, which errors.Is matches.
+type UserError struct {
+	Field string
+	Err   error
+}
+
+func (e *UserError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Err, e.Field)
+}
+
+func (e *UserError) Unwrap() error {
+	return e.Err
+}
+
+type usersRepoInterface interface {
+	CreateUsers(ctx context.Context, users []model.UserGenData) ([]model.User, error)
+	UserByID(ctx context.Context, id int) (model.User, error)
+	UpdateUser(ctx context.Context, id int, patch model.UserPatch) (model.User, error)
+	DeleteUser(ctx context.Context, id int) error
+}
+
+// UsersHandler creates, reads, updates and deletes single users, the writes
+// the paginated reads are exercised under.
+type UsersHandler struct {
+	Repo usersRepoInterface
+}
+
+func NewUsersHandler(repo usersRepoInterface) UsersHandler {
+	return UsersHandler{
+		Repo: repo,
+	}
+}
+
+// Create stores the users after checking every one of them.
+func (h UsersHandler) Create(ctx context.Context, users []model.UserGenData) ([]model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "users-domain", "domain: Create")
+	defer span.End()
+
+	if len(users) == 0 || len(users) > MaxCreateUsers {
+		return nil, fmt.Errorf("%w: %v users, want 1 to %v", ErrInvalidUserBatch, len(users), MaxCreateUsers)
+	}
+	for _, user := range users {
+		if err := checkUser(&user.Name, &user.Surname); err != nil {
+			return nil, err
+		}
+	}
+	return h.Repo.CreateUsers(ctx, users)
+}
+
+// Get reads the user of the id.
+func (h UsersHandler) Get(ctx context.Context, id int) (model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "users-domain", "domain: Get")
+	defer span.End()
+
+	return h.Repo.UserByID(ctx, id)
+}
+
+// Update sets the fields of the patch on the user of the id, a patch
+// without fields only bumps its update time.
+func (h UsersHandler) Update(ctx context.Context, id int, patch model.UserPatch) (model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "users-domain", "domain: Update")
+	defer span.End()
+
+	if err := checkUser(patch.Name, patch.Surname); err != nil {
+		return model.User{}, err
+	}
+	return h.Repo.UpdateUser(ctx, id, patch)
+}
+
+// Delete deletes the user of the id.
+func (h UsersHandler) Delete(ctx context.Context, id int) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "users-domain", "domain: Delete")
+	defer span.End()
+
+	return h.Repo.DeleteUser(ctx, id)
+}
+
+// checkUser checks the given fields are neither empty nor wider than their
+// columns, nil fields aren't checked.
+func checkUser(name, surname *string) error {
+	if name != nil && (*name == "" || utf8.RuneCountInString(*name) > maxNameLen) {
+		return &UserError{Field: "Name", Err: ErrInvalidUser}
+	}
+	if surname != nil && (*surname == "" || utf8.RuneCountInString(*surname) > maxSurnameLen) {
+		return &UserError{Field: "Surname", Err: ErrInvalidUser}
+	}
+	return nil
+}
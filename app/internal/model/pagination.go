@@ -1,18 +1,153 @@
 package model
 
+import "time"
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// filter operators
+const (
+	FilterEq       = "eq"
+	FilterPrefix   = "prefix"
+	FilterContains = "contains"
+)
+
+// Pagination is the page navigation of a limit-offset page. CountMode is the
+// count strategy that produced TotalPages, which is omitted when nothing was
+// counted. Adaptive reads set ServedBy to the read that served the page,
+// offset or keyset, and NextCursor to the cursor the next page continues
+// from when there is one. Pages seeked through the page index are served by
+// index and IndexAge is how long ago the index was built.
 type Pagination struct {
 	CurrentPage int
 	NextPage    int
 	PrevPage    int
-	TotalPages  int
+	TotalPages  int `json:",omitempty"`
+	CountMode   string
+	ServedBy    string  `json:",omitempty"`
+	IndexAge    string  `json:",omitempty"`
+	NextCursor  *string `json:",omitempty"`
 }
 
 type UsersPaginationMetaData struct {
 	Users      UsersData
 	Pagination Pagination
+	Session    *SnapshotSession `json:",omitempty"`
+	Links      *PageLinks       `json:",omitempty"`
 }
 
+// UsersCursorBasedMetaData is a single cursor based page. HasMore reports
+// whether more rows exist past this page in the direction it was read, the
+// cursors are nil when there is nothing to navigate to.
 type UsersCursorBasedMetaData struct {
 	Users      UsersData
-	NextCursor int
+	NextCursor *string
+	PrevCursor *string
+	HasMore    bool
+	Session    *SnapshotSession `json:",omitempty"`
+	Links      *PageLinks       `json:",omitempty"`
+}
+
+// UsersRangeData is an item range of users. First and Last are the zero based
+// positions of its first and last user, Total is nil when nothing was counted.
+type UsersRangeData struct {
+	Users   UsersData
+	First   int
+	Last    int
+	Total   *int
+	Session *SnapshotSession `json:",omitempty"`
+}
+
+// UsersPage is a page of users read with any registered pagination strategy.
+// Total is omitted when the strategy didn't count the users.
+type UsersPage struct {
+	Strategy string
+	ServedBy string `json:",omitempty"`
+	IndexAge string `json:",omitempty"`
+	Users    UsersData
+	Total    *int             `json:",omitempty"`
+	Session  *SnapshotSession `json:",omitempty"`
+	Links    *PageLinks       `json:",omitempty"`
+}
+
+// ResourcePage is a page of a manifest resource read with any of its
+// strategies. Total is omitted when the strategy didn't count the items.
+type ResourcePage struct {
+	Resource string
+	Strategy string
+	ServedBy string `json:",omitempty"`
+	Items    []Record
+	Total    *int             `json:",omitempty"`
+	Session  *SnapshotSession `json:",omitempty"`
+	Links    *PageLinks       `json:",omitempty"`
+}
+
+// PageLinks holds the URLs of the pages around a page, the same URLs are sent
+// in the Link header. A link is empty when its page is unknown or doesn't exist.
+type PageLinks struct {
+	First string `json:",omitempty"`
+	Prev  string `json:",omitempty"`
+	Next  string `json:",omitempty"`
+	Last  string `json:",omitempty"`
+}
+
+// SnapshotSession identifies an open snapshot that later pages can be read from.
+type SnapshotSession struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+// SortField is a single column of a sort order.
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// Filter restricts a listing to rows whose Column matches Value with Op,
+// one of FilterEq, FilterPrefix or the case-insensitive FilterContains.
+type Filter struct {
+	Column string
+	Op     string
+	Value  string
+}
+
+// OffsetQuery describes a single limit-offset read. Deferred runs the offset
+// over an index-only scan of the ids and joins the full rows back afterwards.
+// From is the key of a page boundary the offset counts from instead of the
+// first row, reads with one are sorted by key alone.
+type OffsetQuery struct {
+	From     string
+	Offset   int
+	Limit    int
+	Sort     []SortField
+	Filters  []Filter
+	Deferred bool
+}
+
+// KeysetQuery describes a single cursor based read. Keys holds the values of
+// every Sort column of the row the page is seeked from, no keys reads the
+// first page, or the last one when reading backward. Backward reads the rows
+// that come before the cursor instead of after it.
+type KeysetQuery struct {
+	Sort     []SortField
+	Keys     []string
+	Filters  []Filter
+	Backward bool
+	Limit    int
+}
+
+// Cursor is the payload carried inside an opaque cursor token. Resource is
+// the table and Sort the canonical sort the cursor was issued for, Direction
+// the direction of its leading column and Keys the value of every sort
+// column. Page is the number of the page the cursor ends, only set by
+// adaptive limit-offset reads.
+type Cursor struct {
+	Version   int      `json:"v"`
+	Resource  string   `json:"r"`
+	Direction string   `json:"d"`
+	Sort      string   `json:"s"`
+	Keys      []string `json:"k"`
+	Page      int      `json:"p,omitempty"`
 }
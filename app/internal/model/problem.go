@@ -0,0 +1,16 @@
+package model
+
+// Problem is an RFC 7807 problem details body, served as
+// application/problem+json. Its standard members are named as the RFC names
+// them. Code is the stable machine readable code of the problem, Field the
+// request param or header at fault and TraceID the trace of the request.
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+	Field    string `json:"field,omitempty"`
+	TraceID  string `json:"traceId,omitempty"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Record is a row of a manifest resource, Values holds the value of every
+// column of Columns in the same order.
+type Record struct {
+	Columns []string
+	Values  []any
+}
+
+// MarshalJSON renders the record as an object of its columns in column order.
+// Raw bytes, which the driver returns for types such as numeric, are
+// rendered as text.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range r.Columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(column)
+		if err != nil {
+			return nil, err
+		}
+
+		value := r.Values[i]
+		if raw, ok := value.([]byte); ok {
+			value = string(raw)
+		}
+		encValue, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(encValue)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type UserGenData struct {
 	Name    string
 	Surname string
@@ -12,6 +14,20 @@ type UserData struct {
 
 type UsersData []UserData
 
+// User is a stored user with the times it was created and last updated.
+type User struct {
+	UserData
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// UserPatch holds the fields of a partial user update, nil fields are left
+// as they are.
+type UserPatch struct {
+	Name    *string
+	Surname *string
+}
+
 type ResponseMeta struct {
 	Error   string
 	Success string
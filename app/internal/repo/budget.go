@@ -0,0 +1,139 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrQueryTimeout is returned for reads cancelled by their query budget or
+	// by the deadline of their request.
+	ErrQueryTimeout = errors.New("query timed out")
+	// ErrDBUnavailable is returned for reads that couldn't reach the database
+	// or were turned away by it.
+	ErrDBUnavailable = errors.New("database unavailable")
+)
+
+// queryCanceled is the SQLSTATE of statements cancelled by statement_timeout.
+const queryCanceled = "57014"
+
+// unavailableClasses are the SQLSTATE classes of a database that can't
+// serve the statement now: connection exceptions, insufficient resources
+// and operator intervention.
+var unavailableClasses = []pq.ErrorClass{"08", "53", "57"}
+
+type budgetKey struct{}
+
+type budgetTxKey struct{}
+
+// WithQueryBudget returns a copy of ctx whose reads are cancelled by the
+// database once a statement runs longer than budget. A non positive budget
+// leaves the statements unbounded.
+func WithQueryBudget(ctx context.Context, budget time.Duration) context.Context {
+	return context.WithValue(ctx, budgetKey{}, budget)
+}
+
+// QueryBudget returns the query budget of the context, zero when there is none.
+func QueryBudget(ctx context.Context) time.Duration {
+	budget, _ := ctx.Value(budgetKey{}).(time.Duration)
+	return budget
+}
+
+// Budgeted runs read under the query budget of the context. The budget is
+// set with SET LOCAL statement_timeout, so it applies to the snapshot
+// transaction of the context when there is one and to a read only
+// transaction opened for read otherwise. Reads cancelled by the budget or
+// by the deadline of the context fail with ErrQueryTimeout and reads the
+// database couldn't serve with ErrDBUnavailable.
+func (r RepositoryHandler) Budgeted(ctx context.Context, read func(ctx context.Context) error) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "budget-repo", "repo: Budgeted")
+	defer span.End()
+
+	budget := QueryBudget(ctx)
+	if budget <= 0 {
+		return queryError(ctx, read(ctx))
+	}
+
+	if tx, ok := ctx.Value(snapshotTxKey{}).(*sql.Tx); ok {
+		if err := setStatementTimeout(ctx, tx, budget); err != nil {
+			span.RecordError(err)
+			return err
+		}
+		return queryError(ctx, read(ctx))
+	}
+
+	tx, err := r.Db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		errTrans := fmt.Errorf("failed to open transaction: %w", err)
+		span.RecordError(errTrans)
+		return queryError(ctx, errTrans)
+	}
+	defer tx.Rollback()
+
+	if err := setStatementTimeout(ctx, tx, budget); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err := read(context.WithValue(ctx, budgetTxKey{}, tx)); err != nil {
+		span.RecordError(err)
+		return queryError(ctx, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		errCommit := fmt.Errorf("failed to commit transaction: %w", err)
+		span.RecordError(errCommit)
+		return queryError(ctx, errCommit)
+	}
+	return nil
+}
+
+// setStatementTimeout bounds every later statement of the transaction by budget.
+func setStatementTimeout(ctx context.Context, tx *sql.Tx, budget time.Duration) error {
+	// SET doesn't take bind parameters, the budget is a formatted integer
+	query := fmt.Sprintf("SET LOCAL statement_timeout = %d;", budget.Milliseconds())
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to set statement timeout: %w", err)
+	}
+	return nil
+}
+
+// queryError marks the errors of statements that ran out of time with
+// ErrQueryTimeout and the errors of an unreachable database with
+// ErrDBUnavailable. A statement cancelled because its client went away keeps
+// its error, there is nobody to report the timeout to.
+func queryError(ctx context.Context, err error) error {
+	if err == nil || errors.Is(err, ErrQueryTimeout) || errors.Is(err, ErrDBUnavailable) {
+		return err
+	}
+
+	var pqErr *pq.Error
+	isPqErr := errors.As(err, &pqErr)
+	timedOut := errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		(isPqErr && pqErr.Code == queryCanceled && ctx.Err() == nil)
+	if timedOut {
+		return fmt.Errorf("%w: %w", ErrQueryTimeout, err)
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var netErr net.Error
+	unavailable := errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.As(err, &netErr) ||
+		(isPqErr && pqErr.Code != queryCanceled && slices.Contains(unavailableClasses, pqErr.Code.Class()))
+	if unavailable {
+		return fmt.Errorf("%w: %w", ErrDBUnavailable, err)
+	}
+	return err
+}
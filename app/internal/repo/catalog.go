@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// Column is a column of a table as the catalog describes it.
+type Column struct {
+	Name     string
+	DataType string
+	Nullable bool
+}
+
+// TableColumns returns the columns of a table of the current schema in
+// their table order, none when the table doesn't exist.
+func (r RepositoryHandler) TableColumns(ctx context.Context, table string) ([]Column, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "catalog-repo", "repo: TableColumns")
+	defer span.End()
+
+	query := "SELECT column_name, data_type, is_nullable = 'YES' FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1 ORDER BY ordinal_position;"
+	rows, err := r.Db.QueryContext(ctx, query, table)
+	if err != nil {
+		errQueryExec := fmt.Errorf("TableColumns query exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return nil, errQueryExec
+	}
+
+	defer rows.Close()
+	var columns []Column
+	for rows.Next() {
+		var column Column
+		if err := rows.Scan(&column.Name, &column.DataType, &column.Nullable); err != nil {
+			errQueryScan := fmt.Errorf("TableColumns query scan failed with error: %w", err)
+			span.RecordError(errQueryScan)
+			return columns, errQueryScan
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// IndexedColumns returns the columns leading an index of a table of the
+// current schema, mapped to whether one of those indexes is a unique index
+// of that single column.
+func (r RepositoryHandler) IndexedColumns(ctx context.Context, table string) (map[string]bool, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "catalog-repo", "repo: IndexedColumns")
+	defer span.End()
+
+	query := "SELECT a.attname, i.indisunique AND i.indnkeyatts = 1 FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = i.indkey[0] WHERE c.relname = $1 AND c.relnamespace = current_schema()::regnamespace;"
+	rows, err := r.Db.QueryContext(ctx, query, table)
+	if err != nil {
+		errQueryExec := fmt.Errorf("IndexedColumns query exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return nil, errQueryExec
+	}
+
+	defer rows.Close()
+	columns := map[string]bool{}
+	for rows.Next() {
+		var column string
+		var unique bool
+		if err := rows.Scan(&column, &unique); err != nil {
+			errQueryScan := fmt.Errorf("IndexedColumns query scan failed with error: %w", err)
+			span.RecordError(errQueryScan)
+			return columns, errQueryScan
+		}
+		columns[column] = columns[column] || unique
+	}
+	return columns, rows.Err()
+}
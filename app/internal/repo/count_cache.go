@@ -0,0 +1,81 @@
+package repo
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// CountCache keeps recent users counts in process, keyed by their filters.
+// Entries expire after the TTL and every write to users invalidates them all.
+// A nil *CountCache is a valid, always missing cache.
+type CountCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]countEntry
+}
+
+type countEntry struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewCountCache initializes a CountCache, a non positive TTL disables caching.
+func NewCountCache(ttl time.Duration) *CountCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &CountCache{
+		ttl:     ttl,
+		entries: map[string]countEntry{},
+	}
+}
+
+// Get returns the cached count of the filters, recording the hit or miss.
+func (c *CountCache) Get(filters []model.Filter) (int, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[countKey(filters)]
+	c.mu.Unlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		pkg.CountCacheMisses.Inc()
+		return 0, false
+	}
+
+	pkg.CountCacheHits.Inc()
+	return entry.count, true
+}
+
+// Set caches the count of the filters for the cache TTL.
+func (c *CountCache) Set(filters []model.Filter, count int) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[countKey(filters)] = countEntry{count: count, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// Invalidate drops every cached count, it is called after writes to users.
+func (c *CountCache) Invalidate() {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// countKey identifies a filter set, filters are applied in order so the key is too.
+func countKey(filters []model.Filter) string {
+	return fmt.Sprintf("%q", filters)
+}
@@ -12,6 +12,10 @@ import (
 
 type RepositoryHandler struct {
 	Db *sql.DB
+	// Counts caches TotalUsers results, it may be nil
+	Counts *CountCache
+	// Boundaries is the page index of users rebuilt after writes, it may be nil
+	Boundaries *PageIndex
 }
 
 // Create inserts multiple UserGenData records into the 'users' table
@@ -23,12 +27,33 @@ func (r RepositoryHandler) Create(ctx context.Context, users []model.UserGenData
 	ctx, span := tracerHander.TracerSpan(ctx, "create-users-repo", "repo: Create")
 	defer span.End()
 
+	rows := make([][]any, len(users))
+	for i, user := range users {
+		rows[i] = []any{user.Name, user.Surname}
+	}
+
+	err := r.write(ctx, func(tx *sql.Tx) error {
+		return copyUsers(ctx, tx, []string{"name", "surname"}, rows)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// cached counts no longer hold and the page boundaries shifted
+	r.Counts.Invalidate()
+	r.Boundaries.Refresh()
+
+	return nil
+}
+
+// write runs fn in a transaction bounded by the query budget of the
+// context and commits it when fn succeeds.
+func (r RepositoryHandler) write(ctx context.Context, fn func(tx *sql.Tx) error) (err error) {
 	// Open transaction
-	tx, err := r.Db.Begin()
+	tx, err := r.Db.BeginTx(ctx, nil)
 	if err != nil {
-		errTrans := fmt.Errorf("failed to open transaction: %v", err)
-		span.RecordError(errTrans)
-		return errTrans
+		return queryError(ctx, fmt.Errorf("failed to open transaction: %w", err))
 	}
 	// Ensure rollback on failure
 	defer func() {
@@ -37,155 +62,73 @@ func (r RepositoryHandler) Create(ctx context.Context, users []model.UserGenData
 		}
 	}()
 
-	// Prepare COPY statement
-	stmt, err := tx.Prepare(pq.CopyIn("users", "name", "surname"))
-	if err != nil {
-		errPrepSt := fmt.Errorf("failed to prepare COPY statement: %v", err)
-		span.RecordError(errPrepSt)
-		return errPrepSt
-	}
-	defer stmt.Close()
-
-	// Bulk insert users
-	for _, user := range users {
-		if _, err = stmt.Exec(user.Name, user.Surname); err != nil {
-			errInsert := fmt.Errorf("failed to insert data: %v", err)
-			span.RecordError(errInsert)
-			return errInsert
+	if budget := QueryBudget(ctx); budget > 0 {
+		if err = setStatementTimeout(ctx, tx, budget); err != nil {
+			return queryError(ctx, err)
 		}
 	}
 
-	// Flush remaining data
-	if _, err = stmt.Exec(); err != nil {
-		errFlush := fmt.Errorf("failed to flush data: %v", err)
-		span.RecordError(errFlush)
-		return errFlush
+	if err = fn(tx); err != nil {
+		return queryError(ctx, err)
 	}
 
 	// Commit transaction
 	if err = tx.Commit(); err != nil {
-		errCommit := fmt.Errorf("failed to commit transaction: %v", err)
-		span.RecordError(errCommit)
-		return errCommit
+		return queryError(ctx, fmt.Errorf("failed to commit transaction: %w", err))
 	}
-
 	return nil
 }
 
-func (r RepositoryHandler) LimitOffsetRead(ctx context.Context, offset, limit int) (model.UsersData, error) {
-	// tracer span instance
-	tracerHander := pkg.TracerConfigHandler{}
-	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-repo", "repo: LimitOffsetRead")
-	defer span.End()
-
-	query := `SELECT id, name, surname FROM users ORDER BY id LIMIT $1 OFFSET $2;`
-
-	var usersData model.UsersData
-	rows, err := r.Db.Query(query, limit, offset)
-
+// copyUsers bulk inserts the rows, holding the values of the columns in
+// order, into the 'users' table with a prepared COPY statement.
+func copyUsers(ctx context.Context, tx *sql.Tx, columns []string, rows [][]any) error {
+	// Prepare COPY statement
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("users", columns...))
 	if err != nil {
-		errQueryExec := fmt.Errorf("LimitOffsetReadquery exec failed with error: %v", err)
-		span.RecordError(errQueryExec) // Record error in span
-		return usersData, errQueryExec
+		return fmt.Errorf("failed to prepare COPY statement: %w", err)
 	}
+	defer stmt.Close()
 
-	defer rows.Close()
-	for rows.Next() {
-		var userData model.UserData
-		if err := rows.Scan(&userData.ID, &userData.Name, &userData.Surname); err != nil {
-			errQueryScan := fmt.Errorf("LimitOffsetReadquery scan failed with error: %v", err)
-			span.RecordError(errQueryScan) // Record error in span
-			return usersData, errQueryScan
+	// Bulk insert users
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			return fmt.Errorf("failed to insert data: %w", err)
 		}
-		usersData = append(usersData, userData)
 	}
 
-	return usersData, nil
-}
-
-func (r RepositoryHandler) TotalUsers(ctx context.Context) (int, error) {
-	// tracer span instance
-	tracerHander := pkg.TracerConfigHandler{}
-	ctx, span := tracerHander.TracerSpan(ctx, "total-users-repo", "repo: TotalUsers")
-	defer span.End()
-
-	var count int
-	if err := r.Db.QueryRow("SELECT COUNT(id) FROM users").Scan(&count); err != nil {
-		errQueryExec := fmt.Errorf("TotalUsers query exec failed with error: %v", err)
-		span.RecordError(errQueryExec)
-		return count, errQueryExec
+	// Flush remaining data
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to flush data: %w", err)
 	}
-	return count, nil
+	return nil
 }
 
-func (r RepositoryHandler) CursorBasedRead(ctx context.Context, cursor, limit int) (model.UsersData, error) {
-	if cursor <= 1 {
-		return initCursor(ctx, limit, r.Db)
-	}
-	return actualCursor(ctx, cursor, limit, r.Db)
+// LimitOffsetRead reads a page of the filtered users in the query sort order,
+// see ResourceRepo.LimitOffsetRead.
+func (r RepositoryHandler) LimitOffsetRead(ctx context.Context, query model.OffsetQuery) (model.UsersData, error) {
+	return r.users().LimitOffsetRead(ctx, query)
 }
 
-// handles only cursor less or equal 1
-func initCursor(ctx context.Context, limit int, db *sql.DB) (model.UsersData, error) {
-	// tracer span instance
-	tracerHander := pkg.TracerConfigHandler{}
-	ctx, span := tracerHander.TracerSpan(ctx, "cursor-repo", "repo: initCursor")
-	defer span.End()
-
-	var usersData model.UsersData
-	query := "SELECT id, name, surname FROM users ORDER BY id DESC LIMIT $1;"
-	rows, err := db.Query(query, limit)
-	if err != nil {
-		errQueryExec := fmt.Errorf("CursorBasedRead-initCursor query exec failed with error: %v", err)
-		span.RecordError(errQueryExec) // Record error in span
-		return usersData, errQueryExec
-	}
-
-	defer rows.Close()
-	for rows.Next() {
-		var userData model.UserData
-		if err := rows.Scan(&userData.ID, &userData.UserGenData.Name, &userData.UserGenData.Surname); err != nil {
-			errQueryScan := fmt.Errorf("CursorBasedRead-initCursor query scan failed with error: %v", err)
-			span.RecordError(errQueryScan) // Record error in span
-			return usersData, errQueryScan
-		}
-
-		usersData = append(usersData, userData)
-
-	}
-	return usersData, nil
+// LimitOffsetReadWithTotal reads a page of the filtered users together with
+// their total count, see ResourceRepo.LimitOffsetReadWithTotal.
+func (r RepositoryHandler) LimitOffsetReadWithTotal(ctx context.Context, query model.OffsetQuery) (model.UsersData, int, error) {
+	return r.users().LimitOffsetReadWithTotal(ctx, query)
 }
 
-// handles only cursor greater than 1
-func actualCursor(ctx context.Context, cursor, limit int, db *sql.DB) (model.UsersData, error) {
-	// tracer span instance
-	tracerHander := pkg.TracerConfigHandler{}
-	ctx, span := tracerHander.TracerSpan(ctx, "cursor-repo", "repo: actualCursor")
-	defer span.End()
-
-	var usersData model.UsersData
-
-	query := "SELECT id, name, surname FROM users WHERE id < $1 ORDER BY id DESC LIMIT $2;"
-	rows, err := db.Query(query, cursor, limit)
-	if err != nil {
-		errQueryExec := fmt.Errorf("CursorBasedRead-actualCursor query exec failed with error: %v", err)
-		span.RecordError(errQueryExec) // Record error in span
-		return usersData, errQueryExec
-	}
-
-	defer rows.Close()
-	for rows.Next() {
-		var userData model.UserData
-		if err := rows.Scan(&userData.ID, &userData.UserGenData.Name, &userData.UserGenData.Surname); err != nil {
-			if err := rows.Scan(&userData.ID, &userData.UserGenData.Name, &userData.UserGenData.Surname); err != nil {
-				errQueryScan := fmt.Errorf("CursorBasedRead-actualCursor query scan failed with error: %v", err)
-				span.RecordError(errQueryScan) // Record error in span
-				return usersData, errQueryScan
-			}
-		}
+// TotalUsers counts the users matching the filters, serving recent counts
+// from the count cache when one is set.
+func (r RepositoryHandler) TotalUsers(ctx context.Context, filters []model.Filter) (int, error) {
+	return r.users().Total(ctx, filters)
+}
 
-		usersData = append(usersData, userData)
+// EstimatedUsers estimates the users matching the filters without counting
+// them, see ResourceRepo.Estimated.
+func (r RepositoryHandler) EstimatedUsers(ctx context.Context, filters []model.Filter) (int, error) {
+	return r.users().Estimated(ctx, filters)
+}
 
-	}
-	return usersData, nil
+// CursorBasedRead reads a keyset page of users in the query sort order,
+// see ResourceRepo.CursorBasedRead.
+func (r RepositoryHandler) CursorBasedRead(ctx context.Context, query model.KeysetQuery) (model.UsersData, bool, error) {
+	return r.users().CursorBasedRead(ctx, query)
 }
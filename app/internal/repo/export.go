@@ -0,0 +1,99 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// exportCursor is the name of the server side cursor an export reads through.
+const exportCursor = "users_export"
+
+// ExportUsers streams every user matching the filters, in id order, to emit
+// one batch at a time. The rows are read through a server side cursor inside
+// a read only transaction so only a single batch is ever held in memory.
+// The transaction is bound to ctx, cancelling it aborts the running fetch and
+// rolls the transaction back, and every fetch is bounded by the query budget
+// of ctx. An error returned by emit stops the export.
+func (r RepositoryHandler) ExportUsers(ctx context.Context, filters []model.Filter, batchSize int, emit func(model.UsersData) error) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "export-users-repo", "repo: ExportUsers")
+	defer span.End()
+
+	tx, err := r.Db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		errTrans := fmt.Errorf("failed to open transaction: %w", err)
+		span.RecordError(errTrans)
+		return queryError(ctx, errTrans)
+	}
+	defer tx.Rollback()
+
+	if budget := QueryBudget(ctx); budget > 0 {
+		if err := setStatementTimeout(ctx, tx, budget); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	where, args := filterSQL(filters, 1)
+	declare := fmt.Sprintf("DECLARE %v NO SCROLL CURSOR FOR SELECT id, name, surname FROM users%v ORDER BY id;", exportCursor, where)
+	if _, err := tx.ExecContext(ctx, declare, args...); err != nil {
+		errDeclare := fmt.Errorf("ExportUsers cursor declare failed with error: %w", err)
+		span.RecordError(errDeclare)
+		return errDeclare
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %v;", batchSize, exportCursor)
+	for {
+		batch, err := fetchBatch(ctx, tx, fetch)
+		if err != nil {
+			span.RecordError(err)
+			return queryError(ctx, err)
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		if err := emit(batch); err != nil {
+			span.RecordError(err)
+			return err
+		}
+
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE "+exportCursor+";"); err != nil {
+		errClose := fmt.Errorf("ExportUsers cursor close failed with error: %w", err)
+		span.RecordError(errClose)
+		return errClose
+	}
+
+	if err := tx.Commit(); err != nil {
+		errCommit := fmt.Errorf("failed to commit transaction: %w", err)
+		span.RecordError(errCommit)
+		return errCommit
+	}
+	return nil
+}
+
+// fetchBatch runs one FETCH of the export cursor and returns its rows.
+func fetchBatch(ctx context.Context, tx *sql.Tx, fetch string) (model.UsersData, error) {
+	var usersData model.UsersData
+	rows, err := tx.QueryContext(ctx, fetch)
+	if err != nil {
+		return usersData, fmt.Errorf("ExportUsers cursor fetch failed with error: %w", err)
+	}
+
+	defer rows.Close()
+	usersData, err = scanRows(rows, UsersResource)
+	if err != nil {
+		return usersData, fmt.Errorf("ExportUsers cursor scan failed with error: %w", err)
+	}
+	return usersData, nil
+}
@@ -0,0 +1,54 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// UserFilterColumns is the whitelist of users columns a listing may be filtered on.
+var UserFilterColumns = []string{"name", "surname"}
+
+// likeEscaper escapes the LIKE wildcards of a user supplied value.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// filterSQL returns the WHERE clause of the filters, or an empty string when
+// there are none, with its params bound from $argStart onwards.
+func filterSQL(filters []model.Filter, argStart int) (string, []any) {
+	if len(filters) == 0 {
+		return "", nil
+	}
+
+	var terms []string
+	var args []any
+	for i, filter := range filters {
+		param := argStart + i
+		switch filter.Op {
+		case model.FilterPrefix:
+			terms = append(terms, fmt.Sprintf("%v LIKE $%v", filter.Column, param))
+			args = append(args, likeEscaper.Replace(filter.Value)+"%")
+		case model.FilterContains:
+			terms = append(terms, fmt.Sprintf("%v ILIKE $%v", filter.Column, param))
+			args = append(args, "%"+likeEscaper.Replace(filter.Value)+"%")
+		default:
+			terms = append(terms, fmt.Sprintf("%v = $%v", filter.Column, param))
+			args = append(args, filter.Value)
+		}
+	}
+	return " WHERE " + strings.Join(terms, " AND "), args
+}
+
+// orderBySQL renders the sort as an ORDER BY list, every column is prefixed
+// with the given table alias prefix such as "u.".
+func orderBySQL(sort []model.SortField, prefix string) string {
+	orderBy := make([]string, 0, len(sort))
+	for _, field := range sort {
+		if field.Desc {
+			orderBy = append(orderBy, prefix+field.Column+" DESC")
+			continue
+		}
+		orderBy = append(orderBy, prefix+field.Column)
+	}
+	return strings.Join(orderBy, ", ")
+}
@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// UserSortColumns is the whitelist of users columns a page may be sorted by.
+var UserSortColumns = []string{"id", "name", "surname"}
+
+// keysetSQL builds the query of a keyset read. Backward reads flip every
+// comparison and order direction so the rows next to the cursor come first.
+// Columns sorted in a single direction are compared with one row value,
+// mixed directions are expanded into the equivalent OR chain.
+func keysetSQL[T any](resource Resource[T], query model.KeysetQuery) (string, []any) {
+	var args []any
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %v FROM %v", strings.Join(resource.Columns, ", "), resource.Table)
+
+	// the keys are bound first, the filters after them
+	where, filterArgs := filterSQL(query.Filters, len(query.Keys)+1)
+	if len(query.Keys) > 0 {
+		if where == "" {
+			where = " WHERE " + keysetPredicate(query.Sort, query.Backward)
+		} else {
+			where += " AND " + keysetPredicate(query.Sort, query.Backward)
+		}
+		for _, key := range query.Keys {
+			args = append(args, key)
+		}
+	}
+	sb.WriteString(where)
+	args = append(args, filterArgs...)
+
+	orderBy := make([]string, 0, len(query.Sort))
+	for _, field := range query.Sort {
+		direction := "ASC"
+		if field.Desc != query.Backward {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, field.Column+" "+direction)
+	}
+
+	args = append(args, query.Limit)
+	fmt.Fprintf(&sb, " ORDER BY %v LIMIT $%v;", strings.Join(orderBy, ", "), len(args))
+	return sb.String(), args
+}
+
+// keysetPredicate returns the WHERE clause selecting the rows past the cursor
+// keys, which are bound to $1..$n in sort order.
+func keysetPredicate(sort []model.SortField, backward bool) string {
+	operator := func(field model.SortField) string {
+		if field.Desc != backward {
+			return "<"
+		}
+		return ">"
+	}
+
+	if len(sort) == 1 {
+		return fmt.Sprintf("%v %v $1", sort[0].Column, operator(sort[0]))
+	}
+
+	uniform := true
+	for _, field := range sort[1:] {
+		uniform = uniform && field.Desc == sort[0].Desc
+	}
+
+	if uniform {
+		columns := make([]string, 0, len(sort))
+		params := make([]string, 0, len(sort))
+		for i, field := range sort {
+			columns = append(columns, field.Column)
+			params = append(params, fmt.Sprintf("$%v", i+1))
+		}
+		return fmt.Sprintf("(%v) %v (%v)", strings.Join(columns, ", "), operator(sort[0]), strings.Join(params, ", "))
+	}
+
+	// (a > $1) OR (a = $1 AND b < $2) OR ...
+	var clauses []string
+	for i, field := range sort {
+		var terms []string
+		for j := range i {
+			terms = append(terms, fmt.Sprintf("%v = $%v", sort[j].Column, j+1))
+		}
+		terms = append(terms, fmt.Sprintf("%v %v $%v", field.Column, operator(field), i+1))
+		clauses = append(clauses, "("+strings.Join(terms, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")"
+}
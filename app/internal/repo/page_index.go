@@ -0,0 +1,149 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// PageIndex records the key of every Nth row of a table in key order, the
+// page boundaries, so a read at any offset seeks to the boundary before it
+// and only skips the rows past that boundary. The boundaries are rebuilt by
+// Run on a schedule and after Refresh, they go stale in between as rows are
+// written. A nil *PageIndex has no boundaries.
+type PageIndex struct {
+	Db    *sql.DB
+	Table string
+	Key   string
+
+	every   int
+	mu      sync.RWMutex
+	keys    []string
+	builtAt time.Time
+	refresh chan struct{}
+}
+
+// NewPageIndex initializes a PageIndex recording the key of every Nth row of
+// the table, a non positive every disables the index.
+func NewPageIndex(db *sql.DB, table, key string, every int) *PageIndex {
+	if every < 1 {
+		return nil
+	}
+	return &PageIndex{
+		Db:      db,
+		Table:   table,
+		Key:     key,
+		every:   every,
+		refresh: make(chan struct{}, 1),
+	}
+}
+
+// Build walks the table in key order and swaps in its current boundaries.
+// Reads keep seeking the previous boundaries while the walk runs.
+func (x *PageIndex) Build(ctx context.Context) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "page-index-repo", "repo: Build "+x.Table)
+	defer span.End()
+
+	query := fmt.Sprintf("SELECT %v FROM (SELECT %v, ROW_NUMBER() OVER (ORDER BY %v) - 1 AS position FROM %v) boundaries WHERE position %% $1 = 0 ORDER BY %v;",
+		x.Key, x.Key, x.Key, x.Table, x.Key)
+
+	startedAt := time.Now()
+	rows, err := x.Db.QueryContext(ctx, query, x.every)
+	if err != nil {
+		errQueryExec := fmt.Errorf("Build query exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return errQueryExec
+	}
+	defer rows.Close()
+
+	var keys []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			errScan := fmt.Errorf("Build rows scan failed with error: %w", err)
+			span.RecordError(errScan)
+			return errScan
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		errRows := fmt.Errorf("Build rows failed with error: %w", err)
+		span.RecordError(errRows)
+		return errRows
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	// the boundaries are as old as the walk's view of the table
+	x.keys, x.builtAt = keys, startedAt
+	return nil
+}
+
+// Seek returns the boundary at or before the offset, the number of rows to
+// skip past it and when the boundaries were built. It reports false when
+// there is no boundary past the first row to seek to.
+func (x *PageIndex) Seek(offset int) (string, int, time.Time, bool) {
+	if x == nil {
+		return "", 0, time.Time{}, false
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	// rows written after the walk sit past the last boundary
+	boundary := min(offset/x.every, len(x.keys)-1)
+	if boundary < 1 {
+		return "", 0, time.Time{}, false
+	}
+	return x.keys[boundary], offset - boundary*x.every, x.builtAt, true
+}
+
+// Refresh asks Run to rebuild the boundaries without waiting for it, it is
+// called after writes to the table.
+func (x *PageIndex) Refresh() {
+	if x == nil {
+		return
+	}
+
+	select {
+	case x.refresh <- struct{}{}:
+	default:
+		// a rebuild is already pending
+	}
+}
+
+// Run builds the boundaries and rebuilds them every interval, when it is
+// positive, and after every Refresh until the context is done. Failed builds
+// keep the previous boundaries.
+func (x *PageIndex) Run(ctx context.Context, interval time.Duration) {
+	if x == nil {
+		return
+	}
+
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		if err := x.Build(ctx); err != nil {
+			log.Printf("page index build of %v failed with error: %v", x.Table, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-tick:
+		case <-x.refresh:
+		}
+	}
+}
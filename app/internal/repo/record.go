@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+)
+
+// RecordResource describes a table whose rows are read as records of the
+// given columns, which must hold the key and every sort column since cursors
+// are built from the values read. Text columns are the filter columns of a
+// text type.
+func RecordResource(table, key string, columns, sortColumns, filterColumns, textColumns []string) Resource[model.Record] {
+	return Resource[model.Record]{
+		Table:         table,
+		Alias:         "t",
+		Key:           key,
+		Columns:       columns,
+		SortColumns:   sortColumns,
+		FilterColumns: filterColumns,
+		TextColumns:   textColumns,
+		Scan: func(record *model.Record) []any {
+			record.Columns = columns
+			record.Values = make([]any, len(columns))
+			dest := make([]any, len(columns))
+			for i := range record.Values {
+				dest[i] = &record.Values[i]
+			}
+			return dest
+		},
+		SortKey: func(record model.Record, column string) string {
+			return recordKey(record.Values[slices.Index(record.Columns, column)])
+		},
+	}
+}
+
+// recordKey renders a scanned value as a cursor key postgres can read back.
+func recordKey(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,373 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+// Resource describes a table or view rows of T are paged through. Columns
+// are selected in that order and Scan returns the scan destinations of those
+// columns in an item. Key is the unique column every sort ends with, SortKey
+// returns the value a cursor holds for a sort column of an item. Alias
+// names the table inside deferred joins. TextColumns are the filter columns
+// holding text, the only ones prefix and contains filters apply to.
+type Resource[T any] struct {
+	Table         string
+	Alias         string
+	Key           string
+	Columns       []string
+	SortColumns   []string
+	FilterColumns []string
+	TextColumns   []string
+	Scan          func(item *T) []any
+	SortKey       func(item T, column string) string
+}
+
+// UsersResource is the users table.
+var UsersResource = Resource[model.UserData]{
+	Table:         "users",
+	Alias:         "u",
+	Key:           "id",
+	Columns:       []string{"id", "name", "surname"},
+	SortColumns:   UserSortColumns,
+	FilterColumns: UserFilterColumns,
+	TextColumns:   UserFilterColumns,
+	Scan: func(u *model.UserData) []any {
+		return []any{&u.ID, &u.Name, &u.Surname}
+	},
+	SortKey: func(u model.UserData, column string) string {
+		switch column {
+		case "name":
+			return u.Name
+		case "surname":
+			return u.Surname
+		default:
+			return strconv.Itoa(u.ID)
+		}
+	},
+}
+
+// ResourceRepo reads pages of a resource. Counts are cached in the count
+// cache of its handler, which must therefore not be shared with the repo of
+// another resource.
+type ResourceRepo[T any] struct {
+	Handler  RepositoryHandler
+	Resource Resource[T]
+}
+
+// NewResourceRepo initializes a ResourceRepo reading the resource through the handler.
+func NewResourceRepo[T any](handler RepositoryHandler, resource Resource[T]) ResourceRepo[T] {
+	return ResourceRepo[T]{
+		Handler:  handler,
+		Resource: resource,
+	}
+}
+
+// Budgeted runs read under the query budget of the context, see
+// RepositoryHandler.Budgeted.
+func (r ResourceRepo[T]) Budgeted(ctx context.Context, read func(ctx context.Context) error) error {
+	return r.Handler.Budgeted(ctx, read)
+}
+
+// users returns the repo of the users resource.
+func (r RepositoryHandler) users() ResourceRepo[model.UserData] {
+	return NewResourceRepo(r, UsersResource)
+}
+
+// LimitOffsetRead reads a page of the filtered items in the query sort order.
+// Deferred queries only skip over keys and look the page rows up afterwards,
+// which keeps deep offsets off the table heap.
+func (r ResourceRepo[T]) LimitOffsetRead(ctx context.Context, query model.OffsetQuery) ([]T, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-repo", "repo: LimitOffsetRead "+r.Resource.Table)
+	defer span.End()
+
+	sqlQuery, args := limitOffsetSQL(r.Resource, query, false)
+
+	rows, err := r.Handler.querier(ctx).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		errQueryExec := fmt.Errorf("LimitOffsetRead query exec failed with error: %w", err)
+		span.RecordError(errQueryExec) // Record error in span
+		return nil, errQueryExec
+	}
+
+	defer rows.Close()
+	items, err := scanRows(rows, r.Resource)
+	if err != nil {
+		errQueryScan := fmt.Errorf("LimitOffsetRead query scan failed with error: %w", err)
+		span.RecordError(errQueryScan) // Record error in span
+		return items, errQueryScan
+	}
+	return items, nil
+}
+
+// LimitOffsetReadWithTotal reads a page of the filtered items together with
+// their total count in a single round trip, the count is a COUNT(*) OVER()
+// window computed by the page query itself. A page past the end has no rows
+// to carry the window so the total is counted separately. Cached counts skip
+// the window altogether.
+func (r ResourceRepo[T]) LimitOffsetReadWithTotal(ctx context.Context, query model.OffsetQuery) ([]T, int, error) {
+	if count, ok := r.Handler.counts(ctx).Get(query.Filters); ok {
+		items, err := r.LimitOffsetRead(ctx, query)
+		return items, count, err
+	}
+
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-repo", "repo: LimitOffsetReadWithTotal "+r.Resource.Table)
+	defer span.End()
+
+	sqlQuery, args := limitOffsetSQL(r.Resource, query, true)
+
+	var total int
+	rows, err := r.Handler.querier(ctx).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		errQueryExec := fmt.Errorf("LimitOffsetReadWithTotal query exec failed with error: %w", err)
+		span.RecordError(errQueryExec) // Record error in span
+		return nil, total, errQueryExec
+	}
+
+	defer rows.Close()
+	items, err := scanRows(rows, r.Resource, &total)
+	if err != nil {
+		errQueryScan := fmt.Errorf("LimitOffsetReadWithTotal query scan failed with error: %w", err)
+		span.RecordError(errQueryScan) // Record error in span
+		return items, total, errQueryScan
+	}
+
+	if len(items) == 0 {
+		total, err = r.Total(ctx, query.Filters)
+		return items, total, err
+	}
+
+	r.Handler.counts(ctx).Set(query.Filters, total)
+	return items, total, nil
+}
+
+// Total counts the items matching the filters, serving recent counts from
+// the count cache when one is set.
+func (r ResourceRepo[T]) Total(ctx context.Context, filters []model.Filter) (int, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "total-repo", "repo: Total "+r.Resource.Table)
+	defer span.End()
+
+	if count, ok := r.Handler.counts(ctx).Get(filters); ok {
+		return count, nil
+	}
+
+	where, args := filterSQL(filters, 1)
+	query := fmt.Sprintf("SELECT COUNT(%v) FROM %v%v", r.Resource.Key, r.Resource.Table, where)
+
+	var count int
+	if err := r.Handler.querier(ctx).QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		errQueryExec := fmt.Errorf("Total query exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return count, errQueryExec
+	}
+
+	r.Handler.counts(ctx).Set(filters, count)
+	return count, nil
+}
+
+// Estimated estimates the items matching the filters without counting them.
+// Unfiltered estimates come from the table statistics in pg_class, filtered
+// ones from the planner's row estimate of the filtered query. A table that
+// was never analyzed has no statistics and is counted exactly.
+func (r ResourceRepo[T]) Estimated(ctx context.Context, filters []model.Filter) (int, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "estimated-repo", "repo: Estimated "+r.Resource.Table)
+	defer span.End()
+
+	if len(filters) == 0 {
+		var estimate float64
+		query := "SELECT reltuples FROM pg_class WHERE oid = $1::regclass"
+		if err := r.Handler.querier(ctx).QueryRowContext(ctx, query, r.Resource.Table).Scan(&estimate); err != nil {
+			errQueryExec := fmt.Errorf("Estimated query exec failed with error: %w", err)
+			span.RecordError(errQueryExec)
+			return 0, errQueryExec
+		}
+
+		if estimate < 0 {
+			return r.Total(ctx, filters)
+		}
+		return int(estimate), nil
+	}
+
+	where, args := filterSQL(filters, 1)
+	query := fmt.Sprintf("EXPLAIN (FORMAT JSON) SELECT %v FROM %v%v", r.Resource.Key, r.Resource.Table, where)
+
+	var plan []byte
+	if err := r.Handler.querier(ctx).QueryRowContext(ctx, query, args...).Scan(&plan); err != nil {
+		errQueryExec := fmt.Errorf("Estimated explain exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return 0, errQueryExec
+	}
+
+	var explained []struct {
+		Plan struct {
+			Rows float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(plan, &explained); err != nil || len(explained) == 0 {
+		errDecode := fmt.Errorf("Estimated explain decode failed with error: %w", err)
+		span.RecordError(errDecode)
+		return 0, errDecode
+	}
+
+	return int(explained[0].Plan.Rows), nil
+}
+
+// CursorBasedRead reads a keyset page of items in the query sort order.
+// Backward reads return the rows just before the cursor, still in
+// display order.
+// One row past the limit is looked ahead to report whether more rows
+// exist in the direction of the read, the extra row is not returned.
+func (r ResourceRepo[T]) CursorBasedRead(ctx context.Context, query model.KeysetQuery) ([]T, bool, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "cursor-repo", "repo: CursorBasedRead "+r.Resource.Table)
+	defer span.End()
+
+	lookAhead := query
+	lookAhead.Limit = query.Limit + 1
+	sqlQuery, args := keysetSQL(r.Resource, lookAhead)
+
+	rows, err := r.Handler.querier(ctx).QueryContext(ctx, sqlQuery, args...)
+	if err != nil {
+		errQueryExec := fmt.Errorf("CursorBasedRead query exec failed with error: %w", err)
+		span.RecordError(errQueryExec) // Record error in span
+		return nil, false, errQueryExec
+	}
+
+	defer rows.Close()
+	items, err := scanRows(rows, r.Resource)
+	if err != nil {
+		errQueryScan := fmt.Errorf("CursorBasedRead query scan failed with error: %w", err)
+		span.RecordError(errQueryScan) // Record error in span
+		return items, false, errQueryScan
+	}
+
+	hasMore := len(items) > query.Limit
+	if hasMore {
+		items = items[:query.Limit]
+	}
+
+	// backward reads are fetched nearest to the cursor first
+	if query.Backward {
+		slices.Reverse(items)
+	}
+	return items, hasMore, nil
+}
+
+// SortKeysAt returns the value of every sort column of the row at the zero
+// based query offset, nil when the offset is past the last row. Only the
+// sort columns are read so an index holding them serves the skip without
+// visiting the table heap. A query From a page boundary skips from there.
+func (r ResourceRepo[T]) SortKeysAt(ctx context.Context, query model.OffsetQuery) ([]string, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "limit-offset-repo", "repo: SortKeysAt "+r.Resource.Table)
+	defer span.End()
+
+	columns := make([]string, 0, len(query.Sort))
+	for _, field := range query.Sort {
+		columns = append(columns, field.Column)
+	}
+
+	where, args := fromSQL(r.Resource, query)
+	args = append(args, query.Offset)
+	sqlQuery := fmt.Sprintf("SELECT %v FROM %v%v ORDER BY %v LIMIT 1 OFFSET $%v;",
+		strings.Join(columns, ", "), r.Resource.Table, where, orderBySQL(query.Sort, ""), len(args))
+
+	keys := make([]string, len(columns))
+	dest := make([]any, len(columns))
+	for i := range keys {
+		dest[i] = &keys[i]
+	}
+
+	err := r.Handler.querier(ctx).QueryRowContext(ctx, sqlQuery, args...).Scan(dest...)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		errQueryExec := fmt.Errorf("SortKeysAt query exec failed with error: %w", err)
+		span.RecordError(errQueryExec)
+		return nil, errQueryExec
+	}
+	return keys, nil
+}
+
+// scanRows scans every row into an item of the resource. Extra holds the
+// destinations of the columns selected after the resource columns, such as
+// a window total, which are overwritten by every row.
+func scanRows[T any](rows *sql.Rows, resource Resource[T], extra ...any) ([]T, error) {
+	var items []T
+	for rows.Next() {
+		var item T
+		if err := rows.Scan(append(resource.Scan(&item), extra...)...); err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// fromSQL builds the WHERE clause of the filters of an offset query, which
+// starts at its From boundary when it has one.
+func fromSQL[T any](resource Resource[T], query model.OffsetQuery) (string, []any) {
+	where, args := filterSQL(query.Filters, 1)
+	if query.From != "" {
+		args = append(args, query.From)
+		predicate := fmt.Sprintf("%v >= $%v", resource.Key, len(args))
+		if where == "" {
+			where = " WHERE " + predicate
+		} else {
+			where += " AND " + predicate
+		}
+	}
+	return where, args
+}
+
+// limitOffsetSQL builds the page query of a limit-offset read, withTotal
+// adds the total count of the filtered rows as a last column.
+func limitOffsetSQL[T any](resource Resource[T], query model.OffsetQuery, withTotal bool) (string, []any) {
+	where, args := fromSQL(resource, query)
+	args = append(args, query.Limit, query.Offset)
+	limitParam, offsetParam := len(args)-1, len(args)
+
+	if query.Deferred {
+		prefix := resource.Alias + "."
+		columns := make([]string, 0, len(resource.Columns))
+		for _, column := range resource.Columns {
+			columns = append(columns, prefix+column)
+		}
+
+		pageColumns, totalColumn := resource.Key, ""
+		if withTotal {
+			pageColumns, totalColumn = resource.Key+", COUNT(*) OVER() AS total", ", page.total"
+		}
+		return fmt.Sprintf("SELECT %v%v FROM %v %v JOIN (SELECT %v FROM %v%v ORDER BY %v LIMIT $%v OFFSET $%v) page ON page.%v = %v ORDER BY %v;",
+			strings.Join(columns, ", "), totalColumn, resource.Table, resource.Alias,
+			pageColumns, resource.Table, where, orderBySQL(query.Sort, ""), limitParam, offsetParam,
+			resource.Key, prefix+resource.Key, orderBySQL(query.Sort, prefix)), args
+	}
+
+	totalColumn := ""
+	if withTotal {
+		totalColumn = ", COUNT(*) OVER()"
+	}
+	return fmt.Sprintf("SELECT %v%v FROM %v%v ORDER BY %v LIMIT $%v OFFSET $%v;",
+		strings.Join(resource.Columns, ", "), totalColumn, resource.Table, where, orderBySQL(query.Sort, ""), limitParam, offsetParam), args
+}
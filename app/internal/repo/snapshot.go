@@ -0,0 +1,234 @@
+package repo
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrSessionNotFound is returned for unknown or expired snapshot session tokens.
+	ErrSessionNotFound = errors.New("snapshot session not found or expired")
+	// ErrTooManySessions is returned when opening a session would exceed the open sessions cap.
+	ErrTooManySessions = errors.New("snapshot session limit reached")
+)
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type snapshotTxKey struct{}
+
+// querier returns the snapshot or budget transaction of the context when
+// there is one, the database otherwise.
+func (r RepositoryHandler) querier(ctx context.Context) querier {
+	if tx, ok := ctx.Value(snapshotTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	if tx, ok := ctx.Value(budgetTxKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return r.Db
+}
+
+// counts returns the count cache, which is bypassed inside a snapshot since
+// cached counts may not match the snapshot's view of the table.
+func (r RepositoryHandler) counts(ctx context.Context) *CountCache {
+	if _, ok := ctx.Value(snapshotTxKey{}).(*sql.Tx); ok {
+		return nil
+	}
+	return r.Counts
+}
+
+// SnapshotSessions keeps REPEATABLE READ snapshots open across requests so a
+// client can page through a frozen view of users. Every session holds its
+// exporting transaction, and therefore a pool connection, open until it
+// expires, which is why the number of open sessions is capped.
+// A nil *SnapshotSessions has no session slots.
+type SnapshotSessions struct {
+	Db *sql.DB
+
+	ttl         time.Duration
+	maxSessions int
+	mu          sync.Mutex
+	sessions    map[string]*snapshotSession
+}
+
+type snapshotSession struct {
+	tx         *sql.Tx
+	snapshotID string
+	expiresAt  time.Time
+}
+
+// NewSnapshotSessions initializes SnapshotSessions expiring every session after
+// the TTL and keeping at most maxSessions open at once. A non positive TTL or
+// cap disables snapshot sessions.
+func NewSnapshotSessions(db *sql.DB, ttl time.Duration, maxSessions int) *SnapshotSessions {
+	if ttl <= 0 || maxSessions < 1 {
+		return nil
+	}
+	return &SnapshotSessions{
+		Db:          db,
+		ttl:         ttl,
+		maxSessions: maxSessions,
+		sessions:    map[string]*snapshotSession{},
+	}
+}
+
+// Open starts a REPEATABLE READ transaction, exports its snapshot and returns
+// the session token later reads use to import it.
+func (s *SnapshotSessions) Open(ctx context.Context) (model.SnapshotSession, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "snapshot-repo", "repo: Open")
+	defer span.End()
+
+	var session model.SnapshotSession
+	if s == nil {
+		span.RecordError(ErrTooManySessions)
+		return session, ErrTooManySessions
+	}
+
+	s.mu.Lock()
+	if len(s.sessions) >= s.maxSessions {
+		s.mu.Unlock()
+		span.RecordError(ErrTooManySessions)
+		return session, ErrTooManySessions
+	}
+	// reserve the slot while the snapshot is exported
+	token, err := newSessionToken()
+	if err != nil {
+		s.mu.Unlock()
+		span.RecordError(err)
+		return session, err
+	}
+	s.sessions[token] = nil
+	s.mu.Unlock()
+
+	// the exporting transaction outlives the request, so it is not bound to its context
+	tx, err := s.Db.BeginTx(context.Background(), &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		s.release(token)
+		errTrans := fmt.Errorf("failed to open snapshot transaction: %w", err)
+		span.RecordError(errTrans)
+		return session, queryError(ctx, errTrans)
+	}
+
+	var snapshotID string
+	if err := tx.QueryRowContext(ctx, "SELECT pg_export_snapshot()").Scan(&snapshotID); err != nil {
+		tx.Rollback()
+		s.release(token)
+		errExport := fmt.Errorf("failed to export snapshot: %w", err)
+		span.RecordError(errExport)
+		return session, errExport
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	s.mu.Lock()
+	s.sessions[token] = &snapshotSession{tx: tx, snapshotID: snapshotID, expiresAt: expiresAt}
+	s.mu.Unlock()
+
+	time.AfterFunc(s.ttl, func() { s.Close(token) })
+
+	session.Token = token
+	session.ExpiresAt = expiresAt
+	return session, nil
+}
+
+// Read runs read inside a transaction that imports the snapshot of the session.
+// Repository reads given the context passed to read see the snapshot.
+func (s *SnapshotSessions) Read(ctx context.Context, token string, read func(ctx context.Context) error) (model.SnapshotSession, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "snapshot-repo", "repo: Read")
+	defer span.End()
+
+	var session model.SnapshotSession
+	if s == nil {
+		span.RecordError(ErrSessionNotFound)
+		return session, ErrSessionNotFound
+	}
+
+	s.mu.Lock()
+	snapshot := s.sessions[token]
+	s.mu.Unlock()
+
+	if snapshot == nil || time.Now().After(snapshot.expiresAt) {
+		span.RecordError(ErrSessionNotFound)
+		return session, ErrSessionNotFound
+	}
+
+	tx, err := s.Db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		errTrans := fmt.Errorf("failed to open transaction: %w", err)
+		span.RecordError(errTrans)
+		return session, queryError(ctx, errTrans)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SET TRANSACTION SNAPSHOT "+pq.QuoteLiteral(snapshot.snapshotID)); err != nil {
+		errImport := fmt.Errorf("failed to import snapshot: %w", err)
+		span.RecordError(errImport)
+		return session, errImport
+	}
+
+	if err := read(context.WithValue(ctx, snapshotTxKey{}, tx)); err != nil {
+		span.RecordError(err)
+		return session, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		errCommit := fmt.Errorf("failed to commit transaction: %w", err)
+		span.RecordError(errCommit)
+		return session, errCommit
+	}
+
+	session.Token = token
+	session.ExpiresAt = snapshot.expiresAt
+	return session, nil
+}
+
+// Close ends the session and its exporting transaction, unknown tokens are ignored.
+func (s *SnapshotSessions) Close(token string) {
+	if s == nil {
+		return
+	}
+
+	// the slot is only freed once the connection is back in the pool
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.sessions[token]
+	if snapshot == nil {
+		return
+	}
+	snapshot.tx.Rollback()
+	delete(s.sessions, token)
+}
+
+// release frees a slot reserved by Open.
+func (s *SnapshotSessions) release(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+}
+
+// newSessionToken returns a random session token.
+func newSessionToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+func TestBudgeted(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	repoH := repo.RepositoryHandler{Db: db}
+
+	countQuery := "SELECT COUNT(id) FROM users"
+	read := func(ctx context.Context) error {
+		_, err := repoH.TotalUsers(ctx, []model.Filter(nil))
+		return err
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("no budget", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).WillReturnRows(mock.NewRows([]string{"count"}).AddRow(5))
+
+		if err := repoH.Budgeted(context.Background(), read); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("budget sets the statement timeout", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = 1500;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countQuery).WillReturnRows(mock.NewRows([]string{"count"}).AddRow(5))
+		mock.ExpectCommit()
+
+		ctx := repo.WithQueryBudget(context.Background(), 1500*time.Millisecond)
+		if err := repoH.Budgeted(ctx, read); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("statement timeout", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = 1500;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(countQuery).WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+		mock.ExpectRollback()
+
+		ctx := repo.WithQueryBudget(context.Background(), 1500*time.Millisecond)
+		if err := repoH.Budgeted(ctx, read); !errors.Is(err, repo.ErrQueryTimeout) {
+			t.Errorf("expected error: %v, got %v", repo.ErrQueryTimeout, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("request deadline", func(t *testing.T) {
+		ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+		defer cancel()
+
+		if err := repoH.Budgeted(ctx, read); !errors.Is(err, repo.ErrQueryTimeout) {
+			t.Errorf("expected error: %v, got %v", repo.ErrQueryTimeout, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		for _, dbErr := range []error{
+			&pq.Error{Code: "08006", Message: "connection failure"},
+			&pq.Error{Code: "57P03", Message: "the database system is starting up"},
+		} {
+			mock.ExpectQuery(countQuery).WillReturnError(dbErr)
+
+			if err := repoH.Budgeted(context.Background(), read); !errors.Is(err, repo.ErrDBUnavailable) {
+				t.Errorf("expected error: %v, got %v", repo.ErrDBUnavailable, err)
+			}
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("query error", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).WillReturnError(&pq.Error{Code: "42P01", Message: "relation does not exist"})
+
+		err := repoH.Budgeted(context.Background(), read)
+		if err == nil || errors.Is(err, repo.ErrDBUnavailable) || errors.Is(err, repo.ErrQueryTimeout) {
+			t.Errorf("expected an unclassified error, got %v", err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("client gone", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := repoH.Budgeted(ctx, read)
+		if errors.Is(err, repo.ErrQueryTimeout) || !errors.Is(err, context.Canceled) {
+			t.Errorf("expected error: %v, got %v", context.Canceled, err)
+		}
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,105 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestCountCache(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	filters := []model.Filter{{Column: "name", Op: model.FilterEq, Value: "Jane"}}
+	expectCount := func(count int) {
+		mock.ExpectQuery("SELECT COUNT(id) FROM users WHERE name = $1").
+			WithArgs("Jane").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	assertCount := func(t testing.TB, repoH repo.RepositoryHandler, expected int) {
+		t.Helper()
+		got, err := repoH.TotalUsers(ctx, filters)
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if got != expected {
+			t.Errorf("expected count: %v, got %v", expected, got)
+		}
+	}
+
+	t.Run("hit", func(t *testing.T) {
+		repoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(time.Minute)}
+
+		expectCount(10)
+		assertCount(t, repoH, 10)
+		assertCount(t, repoH, 10)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("other filters miss", func(t *testing.T) {
+		cache := repo.NewCountCache(time.Minute)
+		cache.Set(nil, 100)
+
+		if _, ok := cache.Get(filters); ok {
+			t.Errorf("expected a miss for other filters")
+		}
+		if got, ok := cache.Get(nil); !ok || got != 100 {
+			t.Errorf("expected a hit of %v, got %v", 100, got)
+		}
+	})
+
+	t.Run("invalidate", func(t *testing.T) {
+		repoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(time.Minute)}
+
+		expectCount(10)
+		assertCount(t, repoH, 10)
+
+		repoH.Counts.Invalidate()
+		expectCount(11)
+		assertCount(t, repoH, 11)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		repoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(10 * time.Millisecond)}
+
+		expectCount(10)
+		assertCount(t, repoH, 10)
+
+		time.Sleep(20 * time.Millisecond)
+		expectCount(12)
+		assertCount(t, repoH, 12)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		repoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(0)}
+
+		expectCount(10)
+		assertCount(t, repoH, 10)
+		expectCount(10)
+		assertCount(t, repoH, 10)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -30,6 +30,8 @@ func TestCursorBasedRead(t *testing.T) {
 		)
 	}
 
+	sort := []model.SortField{{Column: "id", Desc: true}}
+
 	assertHelper := func(t testing.TB, mock sqlmock.Sqlmock, got model.UsersData) {
 		t.Helper()
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -44,26 +46,144 @@ func TestCursorBasedRead(t *testing.T) {
 	t.Run("success query", func(t *testing.T) {
 
 		t.Run("init cursor", func(t *testing.T) {
-			cursor, limit := 1, 10
+			limit := 10
 			query := "SELECT id, name, surname FROM users ORDER BY id DESC LIMIT $1;"
-			mock.ExpectQuery(query).WithArgs(limit).WillReturnRows(rows)
-			got, err := repoH.CursorBasedRead(ctx, cursor, limit)
+			mock.ExpectQuery(query).WithArgs(limit + 1).WillReturnRows(rows)
+			got, hasMore, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Limit: limit})
 			if err != nil {
 				t.Errorf("expected no error but got %v", err)
 			}
+			if hasMore {
+				t.Errorf("expected no more rows")
+			}
 			assertHelper(t, mock, got)
 		})
 
 		t.Run("actual cursor", func(t *testing.T) {
-			cursor, limit := 5, 3
+			cursor, limit := "5", 3
 			query := "SELECT id, name, surname FROM users WHERE id < $1 ORDER BY id DESC LIMIT $2;"
-			mock.ExpectQuery(query).WithArgs(cursor, limit).WillReturnRows(rows)
-			_, err := repoH.CursorBasedRead(ctx, cursor, limit)
+			mock.ExpectQuery(query).WithArgs(cursor, limit+1).WillReturnRows(rows)
+			_, _, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Keys: []string{cursor}, Limit: limit})
 			if err != nil {
 				t.Errorf("expected no error but got %v", err)
 			}
 			// assertHelper(t, mock, got)
 		})
 
+		t.Run("before cursor", func(t *testing.T) {
+			cursor, limit := "0", 3
+			query := "SELECT id, name, surname FROM users WHERE id > $1 ORDER BY id ASC LIMIT $2;"
+
+			// rows come back ascending and must be reversed into display order
+			ascRows := mock.NewRows([]string{"id", "name", "surname"})
+			for i := len(usersData) - 1; i >= 0; i-- {
+				ascRows.AddRow(usersData[i].ID, usersData[i].UserGenData.Name, usersData[i].UserGenData.Surname)
+			}
+
+			mock.ExpectQuery(query).WithArgs(cursor, limit+1).WillReturnRows(ascRows)
+			got, _, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Keys: []string{cursor}, Backward: true, Limit: limit})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+			assertHelper(t, mock, got)
+		})
+
+		t.Run("look ahead", func(t *testing.T) {
+			cursor, limit := "10", 2
+			query := "SELECT id, name, surname FROM users WHERE id < $1 ORDER BY id DESC LIMIT $2;"
+
+			lookAheadRows := mock.NewRows([]string{"id", "name", "surname"})
+			for _, user := range usersData {
+				lookAheadRows.AddRow(user.ID, user.UserGenData.Name, user.UserGenData.Surname)
+			}
+
+			mock.ExpectQuery(query).WithArgs(cursor, limit+1).WillReturnRows(lookAheadRows)
+			got, hasMore, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Keys: []string{cursor}, Limit: limit})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+			if !hasMore {
+				t.Errorf("expected more rows past the page")
+			}
+			if !reflect.DeepEqual(got, usersData[:limit]) {
+				t.Errorf("expected data: %v, got %v", usersData[:limit], got)
+			}
+		})
+
+		t.Run("multi column", func(t *testing.T) {
+			testCases := []struct {
+				name     string
+				sort     []model.SortField
+				backward bool
+				query    string
+			}{
+				{
+					name:  "uniform direction",
+					sort:  []model.SortField{{Column: "surname"}, {Column: "name"}, {Column: "id"}},
+					query: "SELECT id, name, surname FROM users WHERE (surname, name, id) > ($1, $2, $3) ORDER BY surname ASC, name ASC, id ASC LIMIT $4;",
+				},
+				{
+					name:     "uniform direction backward",
+					sort:     []model.SortField{{Column: "surname"}, {Column: "name"}, {Column: "id"}},
+					backward: true,
+					query:    "SELECT id, name, surname FROM users WHERE (surname, name, id) < ($1, $2, $3) ORDER BY surname DESC, name DESC, id DESC LIMIT $4;",
+				},
+				{
+					name:  "mixed direction",
+					sort:  []model.SortField{{Column: "surname"}, {Column: "name"}, {Column: "id", Desc: true}},
+					query: "SELECT id, name, surname FROM users WHERE ((surname > $1) OR (surname = $1 AND name > $2) OR (surname = $1 AND name = $2 AND id < $3)) ORDER BY surname ASC, name ASC, id DESC LIMIT $4;",
+				},
+			}
+
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					keys := []string{"Doe", "Jane", "7"}
+					mock.ExpectQuery(tc.query).
+						WithArgs(keys[0], keys[1], keys[2], 11).
+						WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+					_, _, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: tc.sort, Keys: keys, Backward: tc.backward, Limit: 10})
+					if err != nil {
+						t.Errorf("expected no error but got %v", err)
+					}
+					if err := mock.ExpectationsWereMet(); err != nil {
+						t.Errorf("there were unfulfilled expectations: %s", err)
+					}
+				})
+			}
+		})
+
+		t.Run("filtered", func(t *testing.T) {
+			query := "SELECT id, name, surname FROM users WHERE name LIKE $2 AND id < $1 ORDER BY id DESC LIMIT $3;"
+			mock.ExpectQuery(query).
+				WithArgs("7", "Ja%", 11).
+				WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+			_, _, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{
+				Sort:    sort,
+				Keys:    []string{"7"},
+				Filters: []model.Filter{{Column: "name", Op: model.FilterPrefix, Value: "Ja"}},
+				Limit:   10,
+			})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+
+		t.Run("last page", func(t *testing.T) {
+			query := "SELECT id, name, surname FROM users ORDER BY id ASC LIMIT $1;"
+			mock.ExpectQuery(query).WithArgs(11).WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+			_, _, err := repoH.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Backward: true, Limit: 10})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
 	})
 }
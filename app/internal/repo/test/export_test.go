@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestExportUsers(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	repoH := repo.RepositoryHandler{Db: db}
+	ctx := context.Background()
+	usersData := pkg.FakeUsersData(5)
+
+	declare := "DECLARE users_export NO SCROLL CURSOR FOR SELECT id, name, surname FROM users ORDER BY id;"
+	fetch := "FETCH FORWARD 2 FROM users_export;"
+
+	batchRows := func(users model.UsersData) *sqlmock.Rows {
+		rows := mock.NewRows([]string{"id", "name", "surname"})
+		for _, user := range users {
+			rows.AddRow(user.ID, user.UserGenData.Name, user.UserGenData.Surname)
+		}
+		return rows
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("fetches in batches", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(declare).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(usersData[:2]))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(usersData[2:4]))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(usersData[4:]))
+		mock.ExpectExec("CLOSE users_export;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		var got model.UsersData
+		var batches int
+		err := repoH.ExportUsers(ctx, nil, 2, func(users model.UsersData) error {
+			batches++
+			got = append(got, users...)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if batches != 3 {
+			t.Errorf("expected batches: %v, got %v", 3, batches)
+		}
+		if !reflect.DeepEqual(got, usersData) {
+			t.Errorf("expected data: %v, got %v", usersData, got)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("filtered export", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DECLARE users_export NO SCROLL CURSOR FOR SELECT id, name, surname FROM users WHERE name LIKE $1 ORDER BY id;").
+			WithArgs("Ja%").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(nil))
+		mock.ExpectExec("CLOSE users_export;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectCommit()
+
+		filters := []model.Filter{{Column: "name", Op: model.FilterPrefix, Value: "Ja"}}
+		err := repoH.ExportUsers(ctx, filters, 2, func(users model.UsersData) error {
+			t.Errorf("expected no batch, got %v", users)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("emit error rolls back", func(t *testing.T) {
+		errEmit := errors.New("client gone")
+		mock.ExpectBegin()
+		mock.ExpectExec(declare).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(usersData[:2]))
+		mock.ExpectRollback()
+
+		err := repoH.ExportUsers(ctx, nil, 2, func(users model.UsersData) error {
+			return errEmit
+		})
+		if !errors.Is(err, errEmit) {
+			t.Errorf("expected error: %v, got %v", errEmit, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		cancelCtx, cancel := context.WithCancel(ctx)
+		mock.ExpectBegin()
+		mock.ExpectExec(declare).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(fetch).WillReturnRows(batchRows(usersData[:2]))
+		mock.ExpectRollback()
+
+		err := repoH.ExportUsers(cancelCtx, nil, 2, func(users model.UsersData) error {
+			cancel()
+			return nil
+		})
+		if err == nil {
+			t.Errorf("expected an error after cancelling the export")
+		}
+		assertExpectations(t)
+	})
+}
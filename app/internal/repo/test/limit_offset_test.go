@@ -2,9 +2,12 @@ package test
 
 import (
 	"context"
+	"database/sql/driver"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
 	"github.com/John-Dembaremba/pagination-technics/internal/repo"
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 )
@@ -31,7 +34,11 @@ func TestLimitOffsetRead(t *testing.T) {
 
 	t.Run("success query", func(t *testing.T) {
 		mock.ExpectQuery(query).WithArgs(limit, offset).WillReturnRows(rows)
-		got, err := repoH.LimitOffsetRead(ctx, offset, limit)
+		got, err := repoH.LimitOffsetRead(ctx, model.OffsetQuery{
+			Offset: offset,
+			Limit:  limit,
+			Sort:   []model.SortField{{Column: "id"}},
+		})
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("there were unfulfilled expectations: %s", err)
@@ -46,4 +53,231 @@ func TestLimitOffsetRead(t *testing.T) {
 		}
 
 	})
+
+	filters := []model.Filter{
+		{Column: "name", Op: model.FilterEq, Value: "Jane"},
+		{Column: "surname", Op: model.FilterPrefix, Value: "O'_"},
+		{Column: "surname", Op: model.FilterContains, Value: "50%"},
+	}
+	filterArgs := []driver.Value{"Jane", `O'\_%`, `%50\%%`}
+
+	t.Run("sorted and filtered query", func(t *testing.T) {
+		query := "SELECT id, name, surname FROM users WHERE name = $1 AND surname LIKE $2 AND surname ILIKE $3 ORDER BY surname DESC, id LIMIT $4 OFFSET $5;"
+		mock.ExpectQuery(query).
+			WithArgs(append(filterArgs, limit, offset)...).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+		_, err := repoH.LimitOffsetRead(ctx, model.OffsetQuery{
+			Offset:  offset,
+			Limit:   limit,
+			Sort:    []model.SortField{{Column: "surname", Desc: true}, {Column: "id"}},
+			Filters: filters,
+		})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("filtered count", func(t *testing.T) {
+		query := "SELECT COUNT(id) FROM users WHERE name = $1 AND surname LIKE $2 AND surname ILIKE $3"
+		mock.ExpectQuery(query).
+			WithArgs(filterArgs...).
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(7))
+
+		got, err := repoH.TotalUsers(ctx, filters)
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if got != 7 {
+			t.Errorf("expected count: %v, got %v", 7, got)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("deferred join query", func(t *testing.T) {
+		query := "SELECT u.id, u.name, u.surname FROM users u JOIN (SELECT id FROM users WHERE name = $1 ORDER BY surname DESC, id LIMIT $2 OFFSET $3) page ON page.id = u.id ORDER BY u.surname DESC, u.id;"
+		mock.ExpectQuery(query).
+			WithArgs("Jane", limit, 5000).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+		_, err := repoH.LimitOffsetRead(ctx, model.OffsetQuery{
+			Offset:   5000,
+			Limit:    limit,
+			Sort:     []model.SortField{{Column: "surname", Desc: true}, {Column: "id"}},
+			Filters:  filters[:1],
+			Deferred: true,
+		})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("estimated count", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			filters  []model.Filter
+			expect   func()
+			expected int
+		}{
+			{
+				name: "table statistics",
+				expect: func() {
+					mock.ExpectQuery("SELECT reltuples FROM pg_class WHERE oid = $1::regclass").WithArgs("users").
+						WillReturnRows(mock.NewRows([]string{"reltuples"}).AddRow(1500.0))
+				},
+				expected: 1500,
+			},
+			{
+				name: "never analyzed",
+				expect: func() {
+					mock.ExpectQuery("SELECT reltuples FROM pg_class WHERE oid = $1::regclass").WithArgs("users").
+						WillReturnRows(mock.NewRows([]string{"reltuples"}).AddRow(-1.0))
+					mock.ExpectQuery("SELECT COUNT(id) FROM users").
+						WillReturnRows(mock.NewRows([]string{"count"}).AddRow(42))
+				},
+				expected: 42,
+			},
+			{
+				name:    "planner estimate",
+				filters: filters[:1],
+				expect: func() {
+					mock.ExpectQuery("EXPLAIN (FORMAT JSON) SELECT id FROM users WHERE name = $1").
+						WithArgs("Jane").
+						WillReturnRows(mock.NewRows([]string{"QUERY PLAN"}).AddRow(`[{"Plan": {"Node Type": "Seq Scan", "Plan Rows": 12}}]`))
+				},
+				expected: 12,
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				tc.expect()
+				got, err := repoH.EstimatedUsers(ctx, tc.filters)
+				if err != nil {
+					t.Errorf("expected no error but got %v", err)
+				}
+
+				if got != tc.expected {
+					t.Errorf("expected estimate: %v, got %v", tc.expected, got)
+				}
+
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			})
+		}
+	})
+
+	t.Run("page with window total", func(t *testing.T) {
+		testCases := []struct {
+			name     string
+			deferred bool
+			query    string
+		}{
+			{
+				name:  "plain",
+				query: "SELECT id, name, surname, COUNT(*) OVER() FROM users WHERE name = $1 ORDER BY id LIMIT $2 OFFSET $3;",
+			},
+			{
+				name:     "deferred",
+				deferred: true,
+				query:    "SELECT u.id, u.name, u.surname, page.total FROM users u JOIN (SELECT id, COUNT(*) OVER() AS total FROM users WHERE name = $1 ORDER BY id LIMIT $2 OFFSET $3) page ON page.id = u.id ORDER BY u.id;",
+			},
+		}
+
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				totalRows := mock.NewRows([]string{"id", "name", "surname", "total"})
+				for _, user := range usersData {
+					totalRows.AddRow(user.ID, user.UserGenData.Name, user.UserGenData.Surname, 40)
+				}
+				mock.ExpectQuery(tc.query).WithArgs("Jane", limit, offset).WillReturnRows(totalRows)
+
+				got, total, err := repoH.LimitOffsetReadWithTotal(ctx, model.OffsetQuery{
+					Offset:   offset,
+					Limit:    limit,
+					Sort:     []model.SortField{{Column: "id"}},
+					Filters:  filters[:1],
+					Deferred: tc.deferred,
+				})
+				if err != nil {
+					t.Errorf("expected no error but got %v", err)
+				}
+
+				if total != 40 {
+					t.Errorf("expected total: %v, got %v", 40, total)
+				}
+				if !reflect.DeepEqual(got, usersData) {
+					t.Errorf("expected data: %v, got %v", usersData, got)
+				}
+
+				if err := mock.ExpectationsWereMet(); err != nil {
+					t.Errorf("there were unfulfilled expectations: %s", err)
+				}
+			})
+		}
+
+		t.Run("empty page falls back to count", func(t *testing.T) {
+			mock.ExpectQuery("SELECT id, name, surname, COUNT(*) OVER() FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+				WithArgs(limit, 100).
+				WillReturnRows(mock.NewRows([]string{"id", "name", "surname", "total"}))
+			mock.ExpectQuery("SELECT COUNT(id) FROM users").
+				WillReturnRows(mock.NewRows([]string{"count"}).AddRow(40))
+
+			got, total, err := repoH.LimitOffsetReadWithTotal(ctx, model.OffsetQuery{
+				Offset: 100,
+				Limit:  limit,
+				Sort:   []model.SortField{{Column: "id"}},
+			})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+
+			if total != 40 || len(got) != 0 {
+				t.Errorf("expected an empty page of total %v, got %v of total %v", 40, got, total)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+
+		t.Run("cached count skips the window", func(t *testing.T) {
+			cachedRepoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(time.Minute)}
+			cachedRepoH.Counts.Set(nil, 40)
+
+			mock.ExpectQuery("SELECT id, name, surname FROM users ORDER BY id LIMIT $1 OFFSET $2;").
+				WithArgs(limit, offset).
+				WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+
+			_, total, err := cachedRepoH.LimitOffsetReadWithTotal(ctx, model.OffsetQuery{
+				Offset: offset,
+				Limit:  limit,
+				Sort:   []model.SortField{{Column: "id"}},
+			})
+			if err != nil {
+				t.Errorf("expected no error but got %v", err)
+			}
+
+			if total != 40 {
+				t.Errorf("expected total: %v, got %v", 40, total)
+			}
+
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	})
 }
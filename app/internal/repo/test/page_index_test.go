@@ -0,0 +1,95 @@
+package test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestPageIndex(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+
+	buildQuery := "SELECT id FROM (SELECT id, ROW_NUMBER() OVER (ORDER BY id) - 1 AS position FROM users) boundaries WHERE position % $1 = 0 ORDER BY id;"
+
+	t.Run("disabled index", func(t *testing.T) {
+		index := repo.NewPageIndex(db, "users", "id", 0)
+		if index != nil {
+			t.Fatalf("expected no index, got %+v", index)
+		}
+		if _, _, _, ok := index.Seek(500); ok {
+			t.Errorf("expected a nil index not to seek")
+		}
+		index.Refresh()
+	})
+
+	t.Run("seek boundaries", func(t *testing.T) {
+		index := repo.NewPageIndex(db, "users", "id", 100)
+		if _, _, _, ok := index.Seek(250); ok {
+			t.Errorf("expected an unbuilt index not to seek")
+		}
+
+		mock.ExpectQuery(buildQuery).WithArgs(100).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(1).AddRow(104).AddRow(230))
+		if err := index.Build(ctx); err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+
+		testCases := []struct {
+			name   string
+			offset int
+			ok     bool
+			key    string
+			skip   int
+		}{
+			{name: "first block", offset: 40},
+			{name: "on a boundary", offset: 100, ok: true, key: "104"},
+			{name: "past a boundary", offset: 250, ok: true, key: "230", skip: 50},
+			{name: "past the last boundary", offset: 420, ok: true, key: "230", skip: 220},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				key, skip, builtAt, ok := index.Seek(tc.offset)
+				if ok != tc.ok || key != tc.key || skip != tc.skip {
+					t.Errorf("expected seek: %v %v %v, got %v %v %v", tc.key, tc.skip, tc.ok, key, skip, ok)
+				}
+				if ok && builtAt.IsZero() {
+					t.Errorf("expected the build time of the index")
+				}
+			})
+		}
+	})
+
+	t.Run("read from a boundary", func(t *testing.T) {
+		repoH := repo.RepositoryHandler{Db: db}
+		sort := []model.SortField{{Column: "id"}}
+
+		query := "SELECT id, name, surname FROM users WHERE id >= $1 ORDER BY id LIMIT $2 OFFSET $3;"
+		mock.ExpectQuery(query).WithArgs("230", 10, 50).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+		if _, err := repoH.LimitOffsetRead(ctx, model.OffsetQuery{From: "230", Offset: 50, Limit: 10, Sort: sort}); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		deferredQuery := "SELECT u.id, u.name, u.surname FROM users u JOIN (SELECT id FROM users WHERE id >= $1 ORDER BY id LIMIT $2 OFFSET $3) page ON page.id = u.id ORDER BY u.id;"
+		mock.ExpectQuery(deferredQuery).WithArgs("230", 10, 50).
+			WillReturnRows(mock.NewRows([]string{"id", "name", "surname"}))
+		if _, err := repoH.LimitOffsetRead(ctx, model.OffsetQuery{From: "230", Offset: 50, Limit: 10, Sort: sort, Deferred: true}); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package test
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+type order struct {
+	Number int
+	Status string
+}
+
+var ordersResource = repo.Resource[order]{
+	Table:         "orders",
+	Alias:         "o",
+	Key:           "number",
+	Columns:       []string{"number", "status"},
+	SortColumns:   []string{"number", "status"},
+	FilterColumns: []string{"status"},
+	Scan: func(o *order) []any {
+		return []any{&o.Number, &o.Status}
+	},
+	SortKey: func(o order, column string) string {
+		if column == "status" {
+			return o.Status
+		}
+		return strconv.Itoa(o.Number)
+	},
+}
+
+func TestResourceRepo(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	ordersRepo := repo.NewResourceRepo(repo.RepositoryHandler{Db: db}, ordersResource)
+
+	orders := []order{{Number: 7, Status: "paid"}, {Number: 9, Status: "paid"}}
+	sort := []model.SortField{{Column: "status"}, {Column: "number"}}
+	filters := []model.Filter{{Column: "status", Op: model.FilterEq, Value: "paid"}}
+
+	assertHelper := func(t testing.TB, got []order) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+
+		if !reflect.DeepEqual(got, orders) {
+			t.Errorf("expected data: %v, got %v", orders, got)
+		}
+	}
+
+	t.Run("limit offset read", func(t *testing.T) {
+		query := "SELECT number, status, COUNT(*) OVER() FROM orders WHERE status = $1 ORDER BY status, number LIMIT $2 OFFSET $3;"
+		mock.ExpectQuery(query).WithArgs("paid", 2, 4).
+			WillReturnRows(mock.NewRows([]string{"number", "status", "count"}).AddRow(7, "paid", 6).AddRow(9, "paid", 6))
+
+		got, total, err := ordersRepo.LimitOffsetReadWithTotal(ctx, model.OffsetQuery{Offset: 4, Limit: 2, Sort: sort, Filters: filters})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if total != 6 {
+			t.Errorf("expected total: %v, got %v", 6, total)
+		}
+		assertHelper(t, got)
+	})
+
+	t.Run("deferred read", func(t *testing.T) {
+		query := "SELECT o.number, o.status FROM orders o JOIN (SELECT number FROM orders ORDER BY status, number LIMIT $1 OFFSET $2) page ON page.number = o.number ORDER BY o.status, o.number;"
+		mock.ExpectQuery(query).WithArgs(2, 4).
+			WillReturnRows(mock.NewRows([]string{"number", "status"}).AddRow(7, "paid").AddRow(9, "paid"))
+
+		got, err := ordersRepo.LimitOffsetRead(ctx, model.OffsetQuery{Offset: 4, Limit: 2, Sort: sort, Deferred: true})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		assertHelper(t, got)
+	})
+
+	t.Run("keyset read", func(t *testing.T) {
+		query := "SELECT number, status FROM orders WHERE (status, number) > ($1, $2) ORDER BY status ASC, number ASC LIMIT $3;"
+		mock.ExpectQuery(query).WithArgs("new", "3", 3).
+			WillReturnRows(mock.NewRows([]string{"number", "status"}).AddRow(7, "paid").AddRow(9, "paid"))
+
+		got, hasMore, err := ordersRepo.CursorBasedRead(ctx, model.KeysetQuery{Sort: sort, Keys: []string{"new", "3"}, Limit: 2})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if hasMore {
+			t.Errorf("expected no more rows")
+		}
+		assertHelper(t, got)
+	})
+
+	t.Run("total", func(t *testing.T) {
+		mock.ExpectQuery("SELECT COUNT(number) FROM orders WHERE status = $1").WithArgs("paid").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(6))
+
+		total, err := ordersRepo.Total(ctx, filters)
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if total != 6 {
+			t.Errorf("expected total: %v, got %v", 6, total)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+
+	t.Run("sort keys at", func(t *testing.T) {
+		query := "SELECT status, number FROM orders WHERE status = $1 ORDER BY status, number LIMIT 1 OFFSET $2;"
+		mock.ExpectQuery(query).WithArgs("paid", 3).
+			WillReturnRows(mock.NewRows([]string{"status", "number"}).AddRow("paid", 5))
+
+		keys, err := ordersRepo.SortKeysAt(ctx, model.OffsetQuery{Offset: 3, Sort: sort, Filters: filters})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if !reflect.DeepEqual(keys, []string{"paid", "5"}) {
+			t.Errorf("expected keys: %v, got %v", []string{"paid", "5"}, keys)
+		}
+
+		mock.ExpectQuery(query).WithArgs("paid", 30).
+			WillReturnRows(mock.NewRows([]string{"status", "number"}))
+
+		keys, err = ordersRepo.SortKeysAt(ctx, model.OffsetQuery{Offset: 30, Sort: sort, Filters: filters})
+		if err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		if keys != nil {
+			t.Errorf("expected no keys past the last row, got %v", keys)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+)
+
+func TestSnapshotSessions(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	repoH := repo.RepositoryHandler{Db: db, Counts: repo.NewCountCache(time.Minute)}
+	snapshotID := "00000003-0000001B-1"
+
+	expectOpen := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT pg_export_snapshot()").
+			WillReturnRows(mock.NewRows([]string{"pg_export_snapshot"}).AddRow(snapshotID))
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("read imports the snapshot", func(t *testing.T) {
+		sessions := repo.NewSnapshotSessions(db, time.Minute, 2)
+
+		expectOpen()
+		session, err := sessions.Open(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		repoH.Counts.Set(nil, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec("SET TRANSACTION SNAPSHOT '" + snapshotID + "'").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		// the count cache is bypassed inside a snapshot
+		mock.ExpectQuery("SELECT COUNT(id) FROM users").
+			WillReturnRows(mock.NewRows([]string{"count"}).AddRow(100))
+		mock.ExpectCommit()
+
+		var count int
+		got, err := sessions.Read(ctx, session.Token, func(ctx context.Context) error {
+			count, err = repoH.TotalUsers(ctx, nil)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if got != session {
+			t.Errorf("expected session: %v, got %v", session, got)
+		}
+		if count != 100 {
+			t.Errorf("expected count: %v, got %v", 100, count)
+		}
+
+		mock.ExpectRollback()
+		sessions.Close(session.Token)
+		assertExpectations(t)
+	})
+
+	t.Run("open sessions cap", func(t *testing.T) {
+		sessions := repo.NewSnapshotSessions(db, time.Minute, 1)
+
+		expectOpen()
+		session, err := sessions.Open(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		if _, err := sessions.Open(ctx); !errors.Is(err, repo.ErrTooManySessions) {
+			t.Errorf("expected error: %v, got %v", repo.ErrTooManySessions, err)
+		}
+
+		mock.ExpectRollback()
+		sessions.Close(session.Token)
+		assertExpectations(t)
+	})
+
+	t.Run("expired session", func(t *testing.T) {
+		sessions := repo.NewSnapshotSessions(db, 10*time.Millisecond, 1)
+
+		expectOpen()
+		mock.ExpectRollback()
+		session, err := sessions.Open(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		_, err = sessions.Read(ctx, session.Token, func(ctx context.Context) error { return nil })
+		if !errors.Is(err, repo.ErrSessionNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrSessionNotFound, err)
+		}
+
+		// the slot of the expired session is free again
+		expectOpen()
+		session, err = sessions.Open(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		mock.ExpectRollback()
+		sessions.Close(session.Token)
+		assertExpectations(t)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		sessions := repo.NewSnapshotSessions(db, time.Minute, 1)
+		_, err := sessions.Read(ctx, "unknown", func(ctx context.Context) error { return nil })
+		if !errors.Is(err, repo.ErrSessionNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrSessionNotFound, err)
+		}
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		sessions := repo.NewSnapshotSessions(db, time.Minute, 0)
+		if _, err := sessions.Open(ctx); !errors.Is(err, repo.ErrTooManySessions) {
+			t.Errorf("expected error: %v, got %v", repo.ErrTooManySessions, err)
+		}
+	})
+}
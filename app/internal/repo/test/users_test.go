@@ -0,0 +1,175 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+func TestUsersCRUD(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Errorf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+	repoH := repo.RepositoryHandler{Db: db}
+
+	columns := []string{"id", "name", "surname", "created_at", "updated_at"}
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	ctx := context.Background()
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("create users", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT nextval(pg_get_serial_sequence('users', 'id')) FROM generate_series(1, $1);").
+			WithArgs(2).
+			WillReturnRows(mock.NewRows([]string{"nextval"}).AddRow(11).AddRow(12))
+		copyStmt := mock.ExpectPrepare(pq.CopyIn("users", "id", "name", "surname"))
+		copyStmt.ExpectExec().WithArgs(11, "Jane", "Doe").WillReturnResult(sqlmock.NewResult(0, 1))
+		copyStmt.ExpectExec().WithArgs(12, "John", "Roe").WillReturnResult(sqlmock.NewResult(0, 1))
+		copyStmt.ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT id, name, surname, created_at, updated_at FROM users WHERE id = ANY($1) ORDER BY array_position($1, id);").
+			WithArgs(pq.Array([]int{11, 12})).
+			WillReturnRows(mock.NewRows(columns).
+				AddRow(11, "Jane", "Doe", created, created).
+				AddRow(12, "John", "Roe", created, created))
+		mock.ExpectCommit()
+
+		users, err := repoH.CreateUsers(ctx, []model.UserGenData{{Name: "Jane", Surname: "Doe"}, {Name: "John", Surname: "Roe"}})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		want := []model.User{
+			{UserData: model.UserData{ID: 11, UserGenData: model.UserGenData{Name: "Jane", Surname: "Doe"}}, CreatedAt: created, UpdatedAt: created},
+			{UserData: model.UserData{ID: 12, UserGenData: model.UserGenData{Name: "John", Surname: "Roe"}}, CreatedAt: created, UpdatedAt: created},
+		}
+		if !reflect.DeepEqual(users, want) {
+			t.Errorf("expected users: %+v, got %+v", want, users)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("create users rolls back a failed copy", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT nextval(pg_get_serial_sequence('users', 'id')) FROM generate_series(1, $1);").
+			WithArgs(1).
+			WillReturnRows(mock.NewRows([]string{"nextval"}).AddRow(13))
+		mock.ExpectPrepare(pq.CopyIn("users", "id", "name", "surname")).
+			WillReturnError(errors.New("copy failed"))
+		mock.ExpectRollback()
+
+		if _, err := repoH.CreateUsers(ctx, []model.UserGenData{{Name: "Jane", Surname: "Doe"}}); err == nil {
+			t.Error("expected an error but got none")
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("user by id", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, created_at, updated_at FROM users WHERE id = $1;").
+			WithArgs(11).
+			WillReturnRows(mock.NewRows(columns).AddRow(11, "Jane", "Doe", created, updated))
+
+		user, err := repoH.UserByID(ctx, 11)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if user.ID != 11 || user.Name != "Jane" || !user.UpdatedAt.Equal(updated) {
+			t.Errorf("expected user 11 updated at %v, got %+v", updated, user)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("user by id not found", func(t *testing.T) {
+		mock.ExpectQuery("SELECT id, name, surname, created_at, updated_at FROM users WHERE id = $1;").
+			WithArgs(99).
+			WillReturnRows(mock.NewRows(columns))
+
+		if _, err := repoH.UserByID(ctx, 99); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrUserNotFound, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("update user under a budget", func(t *testing.T) {
+		surname := "Smith"
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = 1500;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("UPDATE users SET name = COALESCE($2, name), surname = COALESCE($3, surname), updated_at = now() WHERE id = $1 RETURNING id, name, surname, created_at, updated_at;").
+			WithArgs(11, nil, surname).
+			WillReturnRows(mock.NewRows(columns).AddRow(11, "Jane", surname, created, updated))
+		mock.ExpectCommit()
+
+		budgetCtx := repo.WithQueryBudget(ctx, 1500*time.Millisecond)
+		user, err := repoH.UpdateUser(budgetCtx, 11, model.UserPatch{Surname: &surname})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if user.Surname != surname {
+			t.Errorf("expected surname: %v, got %v", surname, user.Surname)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("update user not found", func(t *testing.T) {
+		name := "Jane"
+		mock.ExpectBegin()
+		mock.ExpectQuery("UPDATE users SET name = COALESCE($2, name), surname = COALESCE($3, surname), updated_at = now() WHERE id = $1 RETURNING id, name, surname, created_at, updated_at;").
+			WithArgs(99, name, nil).
+			WillReturnRows(mock.NewRows(columns))
+		mock.ExpectRollback()
+
+		if _, err := repoH.UpdateUser(ctx, 99, model.UserPatch{Name: &name}); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrUserNotFound, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("delete user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = $1;").WithArgs(11).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		if err := repoH.DeleteUser(ctx, 11); err != nil {
+			t.Errorf("expected no error but got %v", err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("delete user not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = $1;").WithArgs(99).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		if err := repoH.DeleteUser(ctx, 99); !errors.Is(err, repo.ErrUserNotFound) {
+			t.Errorf("expected error: %v, got %v", repo.ErrUserNotFound, err)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("delete user database unavailable", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = $1;").WithArgs(11).
+			WillReturnError(&pq.Error{Code: "57P03", Message: "the database system is starting up"})
+		mock.ExpectRollback()
+
+		if err := repoH.DeleteUser(ctx, 11); !errors.Is(err, repo.ErrDBUnavailable) {
+			t.Errorf("expected error: %v, got %v", repo.ErrDBUnavailable, err)
+		}
+		assertExpectations(t)
+	})
+}
@@ -0,0 +1,197 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+)
+
+// ErrUserNotFound is returned for reads and writes of a user id no row holds.
+var ErrUserNotFound = errors.New("user not found")
+
+// userColumns are the columns a User is scanned from, in scanUser order.
+const userColumns = "id, name, surname, created_at, updated_at"
+
+// CreateUsers bulk inserts the users through the COPY path of Create and
+// returns them as stored, in the given order. COPY doesn't return the ids
+// it inserts, so they are drawn from the users id sequence beforehand.
+func (r RepositoryHandler) CreateUsers(ctx context.Context, users []model.UserGenData) ([]model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "create-users-repo", "repo: CreateUsers")
+	defer span.End()
+
+	var created []model.User
+	err := r.write(ctx, func(tx *sql.Tx) error {
+		ids, err := nextUserIDs(ctx, tx, len(users))
+		if err != nil {
+			return err
+		}
+
+		rows := make([][]any, len(users))
+		for i, user := range users {
+			rows[i] = []any{ids[i], user.Name, user.Surname}
+		}
+		if err := copyUsers(ctx, tx, []string{"id", "name", "surname"}, rows); err != nil {
+			return err
+		}
+
+		created, err = usersByID(ctx, tx, ids)
+		return err
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	// cached counts no longer hold and the page boundaries shifted
+	r.Counts.Invalidate()
+	r.Boundaries.Refresh()
+
+	return created, nil
+}
+
+// UserByID reads the user of the id under the query budget of the context.
+// Returns ErrUserNotFound when there is no such user.
+func (r RepositoryHandler) UserByID(ctx context.Context, id int) (model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "user-by-id-repo", "repo: UserByID")
+	defer span.End()
+
+	var user model.User
+	err := r.Budgeted(ctx, func(ctx context.Context) error {
+		query := fmt.Sprintf("SELECT %v FROM users WHERE id = $1;", userColumns)
+		return scanUser(r.querier(ctx).QueryRowContext(ctx, query, id), &user)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return user, err
+	}
+	return user, nil
+}
+
+// UpdateUser sets the fields of the patch on the user of the id, bumping
+// its updated_at, and returns the updated user. Returns ErrUserNotFound
+// when there is no such user.
+func (r RepositoryHandler) UpdateUser(ctx context.Context, id int, patch model.UserPatch) (model.User, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "update-user-repo", "repo: UpdateUser")
+	defer span.End()
+
+	var user model.User
+	err := r.write(ctx, func(tx *sql.Tx) error {
+		query := fmt.Sprintf(
+			"UPDATE users SET name = COALESCE($2, name), surname = COALESCE($3, surname), updated_at = now() WHERE id = $1 RETURNING %v;",
+			userColumns)
+		return scanUser(tx.QueryRowContext(ctx, query, id, patch.Name, patch.Surname), &user)
+	})
+	if err != nil {
+		span.RecordError(err)
+		return user, err
+	}
+
+	// filtered counts may have changed, the keys of the page boundaries didn't
+	r.Counts.Invalidate()
+
+	return user, nil
+}
+
+// DeleteUser deletes the user of the id. Returns ErrUserNotFound when there
+// is no such user.
+func (r RepositoryHandler) DeleteUser(ctx context.Context, id int) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "delete-user-repo", "repo: DeleteUser")
+	defer span.End()
+
+	err := r.write(ctx, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1;", id)
+		if err != nil {
+			return fmt.Errorf("DeleteUser query exec failed with error: %w", err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("DeleteUser rows affected failed with error: %w", err)
+		}
+		if deleted == 0 {
+			return ErrUserNotFound
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	// cached counts no longer hold and the page boundaries shifted
+	r.Counts.Invalidate()
+	r.Boundaries.Refresh()
+
+	return nil
+}
+
+// nextUserIDs draws n ids from the users id sequence.
+func nextUserIDs(ctx context.Context, tx *sql.Tx, n int) ([]int, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT nextval(pg_get_serial_sequence('users', 'id')) FROM generate_series(1, $1);", n)
+	if err != nil {
+		return nil, fmt.Errorf("nextUserIDs query exec failed with error: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0, n)
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("nextUserIDs scan failed with error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("nextUserIDs rows failed with error: %w", err)
+	}
+	return ids, nil
+}
+
+// usersByID reads the users of the ids in the order of the ids.
+func usersByID(ctx context.Context, tx *sql.Tx, ids []int) ([]model.User, error) {
+	query := fmt.Sprintf("SELECT %v FROM users WHERE id = ANY($1) ORDER BY array_position($1, id);", userColumns)
+	rows, err := tx.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("usersByID query exec failed with error: %w", err)
+	}
+	defer rows.Close()
+
+	users := make([]model.User, 0, len(ids))
+	for rows.Next() {
+		var user model.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("usersByID rows failed with error: %w", err)
+	}
+	return users, nil
+}
+
+// scanUser scans a row of userColumns into user, a missing row is
+// ErrUserNotFound.
+func scanUser(row interface{ Scan(dest ...any) error }, user *model.User) error {
+	err := row.Scan(&user.ID, &user.Name, &user.Surname, &user.CreatedAt, &user.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("scanUser scan failed with error: %w", err)
+	}
+	return nil
+}
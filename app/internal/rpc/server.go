@@ -0,0 +1,196 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/model"
+	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/internal/rpc/userspb"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// page sizes of ListUsers and StreamUsers unless the policy sets its own
+const (
+	// DefaultPageSize is the page size of requests that don't set one.
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page size, larger ones are coerced to it by
+	// lenient policies.
+	MaxPageSize = 1000
+)
+
+// UsersServer serves the users gRPC service on top of the cursor based handler.
+type UsersServer struct {
+	userspb.UnimplementedUsersServiceServer
+	Handler pagination.CursorBasedHandler
+	Policy  pagination.Policy
+}
+
+// NewUsersServer initializes a UsersServer reading through the given handler
+// with page sizes bounded by the policy.
+func NewUsersServer(handler pagination.CursorBasedHandler, policy pagination.Policy) *UsersServer {
+	return &UsersServer{
+		Handler: handler,
+		Policy:  pagination.Policy{DefaultLimit: DefaultPageSize, MaxLimit: MaxPageSize}.Override(policy),
+	}
+}
+
+// NewGrpcServer returns a gRPC server serving the users service. Trace
+// context sent by clients is picked up the same way otelhttp does for HTTP,
+// and every call is bounded by the query budget as HTTP requests are.
+func NewGrpcServer(handler pagination.CursorBasedHandler, policy pagination.Policy, budget time.Duration) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(QueryBudgetUnary(budget)),
+		grpc.StreamInterceptor(QueryBudgetStream(budget)),
+	)
+	userspb.RegisterUsersServiceServer(server, NewUsersServer(handler, policy))
+	return server
+}
+
+// QueryBudgetUnary bounds every statement run for the unary calls it
+// intercepts by budget, a non positive budget leaves them unbounded.
+func QueryBudgetUnary(budget time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(repo.WithQueryBudget(ctx, budget), req)
+	}
+}
+
+// QueryBudgetStream bounds every statement run for the streams it
+// intercepts by budget, a non positive budget leaves them unbounded.
+func QueryBudgetStream(budget time.Duration) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, budgetedStream{ServerStream: stream, ctx: repo.WithQueryBudget(stream.Context(), budget)})
+	}
+}
+
+// budgetedStream is a server stream whose context carries a query budget.
+type budgetedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s budgetedStream) Context() context.Context {
+	return s.ctx
+}
+
+// ListUsers returns a page of users following AIP-158: a page token is the
+// cursor of the next page and an empty next page token marks the last page.
+func (s *UsersServer) ListUsers(ctx context.Context, req *userspb.ListUsersRequest) (*userspb.ListUsersResponse, error) {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(ctx, "users-rpc", "rpc: list-users")
+	defer span.End()
+
+	pageSize, err := s.pageSize(req.GetPageSize())
+	if err != nil {
+		return nil, err
+	}
+
+	page, err := s.Handler.Retrieve(ctx, pagination.CursorRequest{
+		After: req.GetPageToken(),
+		Sort:  req.GetOrderBy(),
+		Limit: pageSize,
+	})
+	if err != nil {
+		span.RecordError(err)
+		return nil, rpcError(err)
+	}
+
+	resp := &userspb.ListUsersResponse{Users: toProtoUsers(page.Users)}
+	if page.NextCursor != nil {
+		resp.NextPageToken = *page.NextCursor
+	}
+	return resp, nil
+}
+
+// StreamUsers sends every user in the requested order, reading one page of
+// batch size users at a time. The walk stops when the client goes away.
+func (s *UsersServer) StreamUsers(req *userspb.StreamUsersRequest, stream grpc.ServerStreamingServer[userspb.User]) error {
+	// tracer span instance
+	tracerHander := pkg.TracerConfigHandler{}
+	ctx, span := tracerHander.TracerSpan(stream.Context(), "users-rpc", "rpc: stream-users")
+	defer span.End()
+
+	batchSize, err := s.pageSize(req.GetBatchSize())
+	if err != nil {
+		return err
+	}
+
+	cursorReq := pagination.CursorRequest{Sort: req.GetOrderBy(), Limit: batchSize}
+	for {
+		page, err := s.Handler.Retrieve(ctx, cursorReq)
+		if err != nil {
+			span.RecordError(err)
+			return rpcError(err)
+		}
+
+		for _, user := range toProtoUsers(page.Users) {
+			if err := stream.Send(user); err != nil {
+				span.RecordError(err)
+				return err
+			}
+		}
+
+		if page.NextCursor == nil {
+			return nil
+		}
+		cursorReq.After = *page.NextCursor
+	}
+}
+
+// pageSize resolves the requested page size, zero being the default one.
+// Page sizes past the max are coerced to it unless the policy is strict.
+func (s *UsersServer) pageSize(size int32) (int, error) {
+	switch {
+	case size < 0:
+		return 0, status.Error(codes.InvalidArgument, "page size must not be negative")
+	case size == 0:
+		return s.Policy.PageSize(), nil
+	}
+
+	limit, _, err := s.Policy.Limit("page_size", int(size))
+	if err != nil {
+		return 0, status.Error(codes.InvalidArgument, "page size out of range")
+	}
+	return limit, nil
+}
+
+// rpcError maps the domain errors to their gRPC status.
+func rpcError(err error) error {
+	switch {
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		return status.Error(codes.InvalidArgument, "invalid page token")
+	case errors.Is(err, pagination.ErrInvalidSort):
+		return status.Error(codes.InvalidArgument, "invalid order by")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, repo.ErrQueryTimeout):
+		return status.Error(codes.DeadlineExceeded, "query timeout")
+	case errors.Is(err, repo.ErrDBUnavailable):
+		return status.Error(codes.Unavailable, "database unavailable")
+	default:
+		log.Printf("users rpc failed with error: %v", err)
+		return status.Error(codes.Internal, "something went wrong")
+	}
+}
+
+// toProtoUsers converts users to their protobuf messages.
+func toProtoUsers(users model.UsersData) []*userspb.User {
+	protoUsers := make([]*userspb.User, 0, len(users))
+	for _, user := range users {
+		protoUsers = append(protoUsers, &userspb.User{
+			Id:      int64(user.ID),
+			Name:    user.Name,
+			Surname: user.Surname,
+		})
+	}
+	return protoUsers
+}
@@ -0,0 +1,203 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
+	"github.com/John-Dembaremba/pagination-technics/internal/rpc/userspb"
+	"github.com/John-Dembaremba/pagination-technics/pkg"
+	"github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dialUsersServer serves the server over an in memory listener for the
+// duration of the test and returns a client of it.
+func dialUsersServer(t *testing.T, server *grpc.Server) userspb.UsersServiceClient {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("client creation failed with error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return userspb.NewUsersServiceClient(conn)
+}
+
+func TestUsersServer(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	handler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{CursorSecret: "secret"})
+	client := dialUsersServer(t, NewGrpcServer(handler, pagination.Policy{}, 0))
+	ctx := context.Background()
+
+	firstPage := "SELECT id, name, surname FROM users ORDER BY id DESC LIMIT $1;"
+	nextPage := "SELECT id, name, surname FROM users WHERE id < $1 ORDER BY id DESC LIMIT $2;"
+	columns := []string{"id", "name", "surname"}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	assertCode := func(t testing.TB, err error, want codes.Code) {
+		t.Helper()
+		if got := status.Code(err); got != want {
+			t.Errorf("expected code: %v, got %v (%v)", want, got, err)
+		}
+	}
+
+	t.Run("list users", func(t *testing.T) {
+		mock.ExpectQuery(firstPage).WithArgs(3).
+			WillReturnRows(mock.NewRows(columns).AddRow(5, "Jane", "Doe").AddRow(4, "John", "Doe").AddRow(3, "Jim", "Doe"))
+
+		resp, err := client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: 2})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(resp.Users) != 2 || resp.Users[0].Id != 5 || resp.Users[1].Id != 4 {
+			t.Errorf("expected users 5 and 4, got %v", resp.Users)
+		}
+		if resp.NextPageToken == "" {
+			t.Fatalf("expected a next page token")
+		}
+
+		mock.ExpectQuery(nextPage).WithArgs("4", 3).
+			WillReturnRows(mock.NewRows(columns).AddRow(3, "Jim", "Doe"))
+
+		resp, err = client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: 2, PageToken: resp.NextPageToken})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(resp.Users) != 1 || resp.NextPageToken != "" {
+			t.Errorf("expected the last page, got %v", resp)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("list users with changed order", func(t *testing.T) {
+		mock.ExpectQuery(firstPage).WithArgs(2).
+			WillReturnRows(mock.NewRows(columns).AddRow(5, "Jane", "Doe").AddRow(4, "John", "Doe"))
+
+		resp, err := client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: 1})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		_, err = client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: 1, PageToken: resp.NextPageToken, OrderBy: "name"})
+		assertCode(t, err, codes.InvalidArgument)
+		assertExpectations(t)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: -1})
+		assertCode(t, err, codes.InvalidArgument)
+
+		_, err = client.ListUsers(ctx, &userspb.ListUsersRequest{PageToken: "tampered"})
+		assertCode(t, err, codes.InvalidArgument)
+	})
+
+	t.Run("stream users", func(t *testing.T) {
+		mock.ExpectQuery(firstPage).WithArgs(3).
+			WillReturnRows(mock.NewRows(columns).AddRow(5, "Jane", "Doe").AddRow(4, "John", "Doe").AddRow(3, "Jim", "Doe"))
+		mock.ExpectQuery(nextPage).WithArgs("4", 3).
+			WillReturnRows(mock.NewRows(columns).AddRow(3, "Jim", "Doe").AddRow(2, "Joe", "Doe").AddRow(1, "Jo", "Doe"))
+		mock.ExpectQuery(nextPage).WithArgs("2", 3).
+			WillReturnRows(mock.NewRows(columns).AddRow(1, "Jo", "Doe"))
+
+		stream, err := client.StreamUsers(ctx, &userspb.StreamUsersRequest{BatchSize: 2})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+
+		var ids []int64
+		for {
+			user, err := stream.Recv()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				t.Fatalf("expected no error but got %v", err)
+			}
+			ids = append(ids, user.Id)
+		}
+
+		if len(ids) != 5 || ids[0] != 5 || ids[4] != 1 {
+			t.Errorf("expected ids 5 to 1, got %v", ids)
+		}
+		assertExpectations(t)
+	})
+}
+
+func TestUsersServerQueryBudget(t *testing.T) {
+	db, mock, err := pkg.DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	handler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{CursorSecret: "secret"})
+	client := dialUsersServer(t, NewGrpcServer(handler, pagination.Policy{}, 250*time.Millisecond))
+	ctx := context.Background()
+
+	firstPage := "SELECT id, name, surname FROM users ORDER BY id DESC LIMIT $1;"
+
+	assertStatus := func(t testing.TB, err error, want codes.Code) {
+		t.Helper()
+		if got := status.Code(err); got != want {
+			t.Errorf("expected code: %v, got %v (%v)", want, got, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("list users timeout", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = 250;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(firstPage).WithArgs(3).
+			WillReturnError(&pq.Error{Code: "57014", Message: "canceling statement due to statement timeout"})
+		mock.ExpectRollback()
+
+		_, err := client.ListUsers(ctx, &userspb.ListUsersRequest{PageSize: 2})
+		assertStatus(t, err, codes.DeadlineExceeded)
+	})
+
+	t.Run("stream users database unavailable", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec("SET LOCAL statement_timeout = 250;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(firstPage).WithArgs(3).
+			WillReturnError(&pq.Error{Code: "57P03", Message: "the database system is starting up"})
+		mock.ExpectRollback()
+
+		stream, err := client.StreamUsers(ctx, &userspb.StreamUsersRequest{BatchSize: 2})
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		_, err = stream.Recv()
+		assertStatus(t, err, codes.Unavailable)
+	})
+}
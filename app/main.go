@@ -2,14 +2,21 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/John-Dembaremba/pagination-technics/internal/api"
 	"github.com/John-Dembaremba/pagination-technics/internal/domain"
 	"github.com/John-Dembaremba/pagination-technics/internal/domain/pagination"
 	"github.com/John-Dembaremba/pagination-technics/internal/repo"
+	"github.com/John-Dembaremba/pagination-technics/internal/rpc"
 	"github.com/John-Dembaremba/pagination-technics/pkg"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
@@ -23,15 +30,24 @@ func main() {
 		log.Fatalf("failed to init database with error: %v", err)
 	}
 
-	migration_query, err := pkg.ReadFile("./schema.sql")
+	migrator, err := pkg.NewMigrator(db)
 	if err != nil {
-		log.Fatalf("failed to read sql schema with error: %v", err)
+		log.Fatalf("failed to load migrations with error: %v", err)
 	}
 
-	if err = pkg.RunMigration(db, migration_query); err != nil {
+	// `migrate up|down [steps]|status` manages the schema without serving
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(context.Background(), migrator, os.Args[2:]); err != nil {
+			log.Fatalf("migrate failed with error: %v", err)
+		}
+		return
+	}
+
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
 		log.Fatalf("failed to run migration with error: %v", err)
 	}
-	log.Println("Migration completed successfully.")
+	log.Printf("Migration completed successfully, %v migrations applied.", len(applied))
 
 	// Tracing
 	log.Println("Initialize Tracer .....")
@@ -42,7 +58,13 @@ func main() {
 
 	}
 
-	repo := repo.RepositoryHandler{Db: db}
+	// shared so seeding and later writes invalidate the cached counts
+	countCache := repo.NewCountCache(env.COUNT_CACHE_TTL)
+	snapshotSessions := repo.NewSnapshotSessions(db, env.SNAPSHOT_SESSION_TTL, env.SNAPSHOT_MAX_SESSIONS)
+	// rebuilt in the background on its schedule and after seeding
+	pageIndex := repo.NewPageIndex(db, repo.UsersResource.Table, repo.UsersResource.Key, env.PAGE_INDEX_EVERY)
+	go pageIndex.Run(context.Background(), env.PAGE_INDEX_REFRESH)
+	repo := repo.RepositoryHandler{Db: db, Counts: countCache, Boundaries: pageIndex}
 	numUsers := 1000
 
 	log.Printf("Seeding %v of users", numUsers)
@@ -78,22 +100,221 @@ func main() {
 		fmt.Fprintf(w, "Hello Paginators are ready")
 	})
 
-	cursorBsdHandler := pagination.NewCursorBasedHandler(db)
-	cursorBsdHttpControler := api.NewCursorBasedHttpController(cursorBsdHandler)
+	// endpoints without a budget of their own use the default one
+	queryBudget := func(budget time.Duration) time.Duration {
+		if budget > 0 {
+			return budget
+		}
+		return env.QUERY_BUDGET
+	}
+
+	// endpoints override the server wide pagination policy
+	basePolicy, err := pagination.ParsePolicy(env.PAGE_POLICY)
+	if err != nil {
+		log.Fatalf("PAGE_POLICY is invalid: %v", err)
+	}
+	pagePolicy := func(name, spec string) pagination.Policy {
+		policy, err := pagination.ParsePolicy(spec)
+		if err != nil {
+			log.Fatalf("%v is invalid: %v", name, err)
+		}
+		return basePolicy.Override(policy)
+	}
+
+	if env.CURSOR_SECRET == "" {
+		log.Fatalf("CURSOR_SECRET must be set to sign cursor tokens")
+	}
+	cursorBsdHandler := pagination.NewCursorBasedHandler(db, pagination.CursorBasedConfig{
+		CursorSecret: env.CURSOR_SECRET,
+		Sessions:     snapshotSessions,
+	})
+	cursorBsdHttpControler := api.NewCursorBasedHttpController(cursorBsdHandler, pagePolicy("PAGE_POLICY_CURSOR_BASED", env.PAGE_POLICY_CURSOR_BASED))
 	mux.Handle("GET /users/cursor-based",
-		otelhttp.NewHandler(
+		api.QueryBudget(otelhttp.NewHandler(
 			http.HandlerFunc(cursorBsdHttpControler.GetUsers),
 			"cursor-based-pagination",
-		))
+		), env.QUERY_BUDGET))
+
+	limitOffsetHandler := pagination.NewLimitOffSetHandler(db, pagination.LimitOffsetConfig{
+		Mode:       env.LIMIT_OFFSET_MODE,
+		CountMode:  env.COUNT_MODE,
+		CountCache: countCache,
+		Sessions:   snapshotSessions,
 
-	limitOffsetHandler := pagination.NewLimitOffSetHandler(db)
-	limitOffsetHttpController := api.NewLimitOffsetHttpControler(limitOffsetHandler)
+		AdaptiveThreshold: env.ADAPTIVE_OFFSET_THRESHOLD,
+		CursorSecret:      env.CURSOR_SECRET,
+		Boundaries:        pageIndex,
+	})
+	limitOffsetHttpController := api.NewLimitOffsetHttpControler(limitOffsetHandler, pagePolicy("PAGE_POLICY_LIMIT_OFFSET", env.PAGE_POLICY_LIMIT_OFFSET))
 
 	mux.Handle("GET /users/limit-offset",
-		otelhttp.NewHandler(
+		api.QueryBudget(otelhttp.NewHandler(
 			http.HandlerFunc(limitOffsetHttpController.GetUsers),
 			"limit-offset-pagination",
-		))
+		), queryBudget(env.QUERY_BUDGET_LIMIT_OFFSET)))
+
+	// users are one registered resource of the generic paginator
+	usersPaginator := pagination.NewUsersPaginator(db, pagination.PaginatorConfig{
+		Mode:         env.LIMIT_OFFSET_MODE,
+		CountMode:    env.COUNT_MODE,
+		CountCache:   countCache,
+		Sessions:     snapshotSessions,
+		CursorSecret: env.CURSOR_SECRET,
+
+		AdaptiveThreshold: env.ADAPTIVE_OFFSET_THRESHOLD,
+		Boundaries:        pageIndex,
+		Policy:            pagePolicy("PAGE_POLICY_USERS", env.PAGE_POLICY_USERS),
+	})
+	usersHttpController := api.NewUsersHttpController(pagination.NewResourceRegistry(usersPaginator))
+	mux.Handle("GET /users",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(usersHttpController.GetUsers),
+			"users-pagination",
+		), env.QUERY_BUDGET))
+
+	// single user writes the paginated reads are exercised under
+	userHttpController := api.NewUserHttpController(domain.NewUsersHandler(repo))
+	mux.Handle("POST /users",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(userHttpController.CreateUsers),
+			"users-create",
+		), env.QUERY_BUDGET))
+	mux.Handle("GET /users/{id}",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(userHttpController.GetUser),
+			"users-get",
+		), env.QUERY_BUDGET))
+	mux.Handle("PATCH /users/{id}",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(userHttpController.PatchUser),
+			"users-patch",
+		), env.QUERY_BUDGET))
+	mux.Handle("DELETE /users/{id}",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(userHttpController.DeleteUser),
+			"users-delete",
+		), env.QUERY_BUDGET))
+
+	graphQLHttpController, err := api.NewGraphQLHttpController(cursorBsdHandler, limitOffsetHandler, pagePolicy("PAGE_POLICY_GRAPHQL", env.PAGE_POLICY_GRAPHQL))
+	if err != nil {
+		log.Fatalf("GraphQL schema init failed with error: %v", err)
+	}
+	graphQLHandler := api.QueryBudget(otelhttp.NewHandler(http.HandlerFunc(graphQLHttpController.Query), "graphql"), env.QUERY_BUDGET)
+	mux.Handle("GET /graphql", graphQLHandler)
+	mux.Handle("POST /graphql", graphQLHandler)
+
+	exportHandler := pagination.NewExportHandler(db, env.EXPORT_BATCH_SIZE)
+	exportHttpController := api.NewExportHttpController(exportHandler)
+	mux.Handle("GET /users/export",
+		api.QueryBudget(otelhttp.NewHandler(
+			http.HandlerFunc(exportHttpController.GetUsers),
+			"users-export",
+		), queryBudget(env.QUERY_BUDGET_EXPORT)))
+
+	if env.RESOURCES_MANIFEST != "" {
+		registerManifestResources(mux, db, env, pagePolicy("PAGE_POLICY_RESOURCES", env.PAGE_POLICY_RESOURCES))
+	}
+
+	if env.GrpcPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%v", env.GrpcPort))
+		if err != nil {
+			log.Fatalf("failed to listen on gRPC port with error: %v", err)
+		}
+		grpcServer := rpc.NewGrpcServer(cursorBsdHandler, pagePolicy("PAGE_POLICY_GRPC", env.PAGE_POLICY_GRPC), env.QUERY_BUDGET)
+		defer grpcServer.GracefulStop()
+
+		log.Printf("Starting gRPC Server on port: %v\n", env.GrpcPort)
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Printf("gRPC server stopped with error: %v", err)
+			}
+		}()
+	}
 
 	http.ListenAndServe(fmt.Sprintf(":%v", env.ServerPort), mux)
 }
+
+// migrate runs the migrate command: up applies every pending migration,
+// down rolls back the latest applied one or the given number of them and
+// status lists every migration with when it was applied.
+func migrate(ctx context.Context, migrator pkg.Migrator, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: migrate up|down [steps]|status")
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			log.Printf("Applied migration %04d_%v", migration.Version, migration.Name)
+		}
+		return err
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return fmt.Errorf("down steps must be a positive number, got %v", args[1])
+			}
+			steps = n
+		}
+		rolledBack, err := migrator.Down(ctx, steps)
+		for _, migration := range rolledBack {
+			log.Printf("Rolled back migration %04d_%v", migration.Version, migration.Name)
+		}
+		return err
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.Applied {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%04d\t%v\t%v\n", status.Version, status.Name, appliedAt)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown migrate command %v, want up, down or status", args[0])
+	}
+}
+
+// registerManifestResources serves every resource of the manifest under
+// GET /resources/{name} once its tables are confirmed to hold the listed
+// columns and indexes. Their rows change outside the app, so their counts
+// aren't cached. Every resource may override the pagination policy.
+func registerManifestResources(mux *http.ServeMux, db *sql.DB, env pkg.Env, policy pagination.Policy) {
+	manifest, err := pagination.LoadManifest(env.RESOURCES_MANIFEST)
+	if err != nil {
+		log.Fatalf("failed to load resources manifest with error: %v", err)
+	}
+	if err := pagination.CheckManifest(context.Background(), db, &manifest); err != nil {
+		log.Fatalf("resources manifest check failed with error: %v", err)
+	}
+
+	for _, resource := range manifest.Resources {
+		registry, err := pagination.NewManifestRegistry(db, resource, pagination.PaginatorConfig{
+			Mode:         env.LIMIT_OFFSET_MODE,
+			CountMode:    env.COUNT_MODE,
+			CursorSecret: env.CURSOR_SECRET,
+
+			AdaptiveThreshold: env.ADAPTIVE_OFFSET_THRESHOLD,
+			Policy:            policy,
+		})
+		if err != nil {
+			log.Fatalf("failed to register resource %v with error: %v", resource.Name, err)
+		}
+
+		resourceHttpController := api.NewResourceHttpController(resource.Name, registry)
+		mux.Handle("GET /resources/"+resource.Name,
+			api.QueryBudget(otelhttp.NewHandler(
+				http.HandlerFunc(resourceHttpController.GetItems),
+				resource.Name+"-pagination",
+			), env.QUERY_BUDGET))
+		log.Printf("Serving resource %v from table %v", resource.Name, resource.Table)
+	}
+}
@@ -2,10 +2,10 @@ package pkg
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -13,6 +13,7 @@ import (
 type Env struct {
 	ProjectVersion          string `mapstructure:"PROJECT_VERSION"`
 	ServerPort              string `mapstructure:"SERVER_PORT"`
+	GrpcPort                string `mapstructure:"GRPC_PORT"`
 	POSTGRES_CONTAINER_NAME string `mapstructure:"POSTGRES_CONTAINER_NAME"`
 	POSTGRES_VERSION        string `mapstructure:"POSTGRES_VERSION"`
 	POSTGRES_DB             string `mapstructure:"POSTGRES_DB"`
@@ -23,6 +24,34 @@ type Env struct {
 
 	JAEGER_HOST    string `mapstructure:"JAEGER_HOST"`
 	OTLP_HTTP_PORT int    `mapstructure:"OTLP_HTTP_PORT"`
+
+	CURSOR_SECRET             string        `mapstructure:"CURSOR_SECRET"`
+	LIMIT_OFFSET_MODE         string        `mapstructure:"LIMIT_OFFSET_MODE"`
+	ADAPTIVE_OFFSET_THRESHOLD int           `mapstructure:"ADAPTIVE_OFFSET_THRESHOLD"`
+	COUNT_MODE                string        `mapstructure:"COUNT_MODE"`
+	COUNT_CACHE_TTL           time.Duration `mapstructure:"COUNT_CACHE_TTL"`
+
+	SNAPSHOT_SESSION_TTL  time.Duration `mapstructure:"SNAPSHOT_SESSION_TTL"`
+	SNAPSHOT_MAX_SESSIONS int           `mapstructure:"SNAPSHOT_MAX_SESSIONS"`
+
+	PAGE_INDEX_EVERY   int           `mapstructure:"PAGE_INDEX_EVERY"`
+	PAGE_INDEX_REFRESH time.Duration `mapstructure:"PAGE_INDEX_REFRESH"`
+
+	QUERY_BUDGET              time.Duration `mapstructure:"QUERY_BUDGET"`
+	QUERY_BUDGET_LIMIT_OFFSET time.Duration `mapstructure:"QUERY_BUDGET_LIMIT_OFFSET"`
+	QUERY_BUDGET_EXPORT       time.Duration `mapstructure:"QUERY_BUDGET_EXPORT"`
+
+	PAGE_POLICY              string `mapstructure:"PAGE_POLICY"`
+	PAGE_POLICY_CURSOR_BASED string `mapstructure:"PAGE_POLICY_CURSOR_BASED"`
+	PAGE_POLICY_LIMIT_OFFSET string `mapstructure:"PAGE_POLICY_LIMIT_OFFSET"`
+	PAGE_POLICY_USERS        string `mapstructure:"PAGE_POLICY_USERS"`
+	PAGE_POLICY_GRAPHQL      string `mapstructure:"PAGE_POLICY_GRAPHQL"`
+	PAGE_POLICY_GRPC         string `mapstructure:"PAGE_POLICY_GRPC"`
+	PAGE_POLICY_RESOURCES    string `mapstructure:"PAGE_POLICY_RESOURCES"`
+
+	EXPORT_BATCH_SIZE int `mapstructure:"EXPORT_BATCH_SIZE"`
+
+	RESOURCES_MANIFEST string `mapstructure:"RESOURCES_MANIFEST"`
 }
 
 func NewEnv() Env {
@@ -72,24 +101,3 @@ func findEnvFilePath() (string, error) {
 	}
 	return "", fmt.Errorf(".env file not found")
 }
-
-func ReadFile(path string) (string, error) {
-	// Get the absolute path of the schema file
-	absPath, err := filepath.Abs(filepath.Join("pkg", path))
-	if err != nil {
-		return "", err
-	}
-
-	file, err := os.Open(absPath)
-	if err != nil {
-		return "", err
-	}
-	defer file.Close()
-
-	content, err := io.ReadAll(file)
-	if err != nil {
-		return "", err
-	}
-
-	return string(content), nil
-}
@@ -42,12 +42,6 @@ func NewPgDb(cntName, dbName, dbUser, dbPsw, dbPort string) (*sql.DB, error) {
 	return db, nil
 }
 
-func RunMigration(db *sql.DB, query string) error {
-	_, err := db.Exec(query)
-
-	return err
-}
-
 func getMd5(userName, password string) string {
 	// "pagy" "md5ac77bfe847b783150cc181043bd7d2d7"
 	combined := password + userName
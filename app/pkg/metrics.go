@@ -8,9 +8,21 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+var (
+	CountCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pagination_count_cache_hits_total",
+		Help: "Number of total counts served from the in-process count cache.",
+	})
+	CountCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "pagination_count_cache_misses_total",
+		Help: "Number of total counts that missed the in-process count cache.",
+	})
+)
+
 func NewPromMetricsHttpHandler() http.Handler {
 	reg := prometheus.NewRegistry()
 	reg.MustRegister(collectors.NewGoCollector())
+	reg.MustRegister(CountCacheHits, CountCacheMisses)
 	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
 
 }
@@ -0,0 +1,272 @@
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// migrationFiles holds the numbered migrations of the schema, every version
+// has a NNNN_name.up.sql and a NNNN_name.down.sql file.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLockKey is the postgres advisory lock key held while migrating,
+// so replicas booting together apply every migration once.
+const migrationLockKey int64 = 7_246_385_012
+
+// migrationFile matches the name of a migration file.
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+var (
+	// ErrInvalidMigration is returned for migration files that can't be loaded.
+	ErrInvalidMigration = errors.New("invalid migration")
+	// ErrUnknownMigration is returned when rolling back an applied version
+	// this build has no migration of.
+	ErrUnknownMigration = errors.New("unknown migration")
+)
+
+// Migration is a schema version, Up applies it and Down rolls it back.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus is a migration and when it was applied, if it was.
+// Applied versions missing from the build are listed without statements.
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// LoadMigrations reads the migration files at the root of files in version order.
+func LoadMigrations(files fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			return nil, fmt.Errorf("%w: %v is not a NNNN_name.up.sql or NNNN_name.down.sql file", ErrInvalidMigration, entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("%w: version %v is named both %v and %v", ErrInvalidMigration, version, migration.Name, match[2])
+		}
+
+		content, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %v: %w", entry.Name(), err)
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("%w: version %v needs both an up and a down file", ErrInvalidMigration, migration.Version)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return a.Version - b.Version })
+	return migrations, nil
+}
+
+// Migrator applies and rolls back migrations, recording the applied
+// versions in the schema_migrations table. Every run holds an advisory
+// lock, and every migration runs in its own transaction with its record.
+type Migrator struct {
+	Db         *sql.DB
+	Migrations []Migration
+}
+
+// NewMigrator initializes a Migrator of the migrations embedded in the build.
+func NewMigrator(db *sql.DB) (Migrator, error) {
+	files, err := fs.Sub(migrationFiles, "migrations")
+	if err != nil {
+		return Migrator{}, err
+	}
+	migrations, err := LoadMigrations(files)
+	if err != nil {
+		return Migrator{}, err
+	}
+	return Migrator{Db: db, Migrations: migrations}, nil
+}
+
+// Up applies every pending migration in version order and returns the ones
+// it applied.
+func (m Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, statuses []MigrationStatus) error {
+		for _, status := range statuses {
+			if status.Applied {
+				continue
+			}
+			if err := m.run(ctx, conn, status.Up, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2);", status.Version, status.Name); err != nil {
+				return fmt.Errorf("migration %v_%v up failed with error: %w", status.Version, status.Name, err)
+			}
+			applied = append(applied, status.Migration)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down rolls back the latest steps applied migrations and returns the ones
+// it rolled back, latest first.
+func (m Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var rolledBack []Migration
+	err := m.locked(ctx, func(conn *sql.Conn, statuses []MigrationStatus) error {
+		for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+			status := statuses[i]
+			if !status.Applied {
+				continue
+			}
+			if status.Down == "" {
+				return fmt.Errorf("%w: version %v", ErrUnknownMigration, status.Version)
+			}
+			if err := m.run(ctx, conn, status.Down, "DELETE FROM schema_migrations WHERE version = $1;", status.Version); err != nil {
+				return fmt.Errorf("migration %v_%v down failed with error: %w", status.Version, status.Name, err)
+			}
+			rolledBack = append(rolledBack, status.Migration)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Status returns every migration in version order with whether it is
+// applied. It only reads, neither waiting for the migration lock nor
+// creating schema_migrations, every migration is pending until it exists.
+func (m Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	conn, err := m.Db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	var exists bool
+	if err := conn.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL;").Scan(&exists); err != nil {
+		return nil, fmt.Errorf("schema_migrations lookup failed with error: %w", err)
+	}
+	if !exists {
+		return m.pending(), nil
+	}
+	return m.statuses(ctx, conn)
+}
+
+// locked runs fn on a connection holding the migration lock, with the
+// status of every migration read once the lock is held.
+func (m Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, statuses []MigrationStatus) error) error {
+	// session level advisory locks belong to a connection, not the pool
+	conn, err := m.Db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1);", migrationLockKey); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1);", migrationLockKey)
+
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());"); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	statuses, err := m.statuses(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(conn, statuses)
+}
+
+// statuses merges the applied versions into the migrations of the build.
+func (m Migrator) statuses(ctx context.Context, conn *sql.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations ORDER BY version;")
+	if err != nil {
+		return nil, fmt.Errorf("schema_migrations query exec failed with error: %w", err)
+	}
+	defer rows.Close()
+
+	statuses := m.pending()
+	for rows.Next() {
+		var applied MigrationStatus
+		if err := rows.Scan(&applied.Version, &applied.Name, &applied.AppliedAt); err != nil {
+			return nil, fmt.Errorf("schema_migrations scan failed with error: %w", err)
+		}
+		applied.Applied = true
+
+		i := slices.IndexFunc(statuses, func(s MigrationStatus) bool { return s.Version == applied.Version })
+		if i < 0 {
+			statuses = append(statuses, applied)
+			continue
+		}
+		statuses[i].Applied, statuses[i].AppliedAt = true, applied.AppliedAt
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("schema_migrations rows failed with error: %w", err)
+	}
+
+	slices.SortFunc(statuses, func(a, b MigrationStatus) int { return a.Version - b.Version })
+	return statuses, nil
+}
+
+// pending returns the migrations of the build, none of them applied.
+func (m Migrator) pending() []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		statuses = append(statuses, MigrationStatus{Migration: migration})
+	}
+	return statuses
+}
+
+// run executes the statements of a migration and its schema_migrations
+// record in one transaction.
+func (m Migrator) run(ctx context.Context, conn *sql.Conn, statements, record string, args ...any) (err error) {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to open transaction: %w", err)
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err = tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, record, args...); err != nil {
+		return fmt.Errorf("failed to record migration: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Run("embedded migrations", func(t *testing.T) {
+		migrator, err := NewMigrator(nil)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		for i, migration := range migrator.Migrations {
+			if migration.Version != i+1 {
+				t.Errorf("expected version: %v, got %v", i+1, migration.Version)
+			}
+		}
+	})
+
+	testCases := []struct {
+		name  string
+		files fstest.MapFS
+	}{
+		{
+			name:  "missing down",
+			files: fstest.MapFS{"0001_users.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+		{
+			name: "mismatched names",
+			files: fstest.MapFS{
+				"0001_users.up.sql":    {Data: []byte("SELECT 1;")},
+				"0001_people.down.sql": {Data: []byte("SELECT 1;")},
+			},
+		},
+		{
+			name:  "unnumbered file",
+			files: fstest.MapFS{"users.up.sql": {Data: []byte("SELECT 1;")}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := LoadMigrations(tc.files); !errors.Is(err, ErrInvalidMigration) {
+				t.Errorf("expected error: %v, got %v", ErrInvalidMigration, err)
+			}
+		})
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	db, mock, err := DataDogDbMock()
+	if err != nil {
+		t.Fatalf("db mock failed with error: %v", err)
+	}
+	defer db.Close()
+
+	migrator := Migrator{Db: db, Migrations: []Migration{
+		{Version: 1, Name: "create_users", Up: "CREATE TABLE users (id SERIAL);", Down: "DROP TABLE users;"},
+		{Version: 2, Name: "users_name", Up: "ALTER TABLE users ADD COLUMN name TEXT;", Down: "ALTER TABLE users DROP COLUMN name;"},
+	}}
+	appliedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ctx := context.Background()
+
+	expectLocked := func(applied *sqlmock.Rows) {
+		mock.ExpectExec("SELECT pg_advisory_lock($1);").WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT PRIMARY KEY, name TEXT NOT NULL, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations ORDER BY version;").WillReturnRows(applied)
+	}
+	expectUnlock := func() {
+		mock.ExpectExec("SELECT pg_advisory_unlock($1);").WithArgs(migrationLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	appliedRows := func() *sqlmock.Rows {
+		return mock.NewRows([]string{"version", "name", "applied_at"})
+	}
+
+	assertExpectations := func(t testing.TB) {
+		t.Helper()
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+
+	t.Run("up applies pending migrations", func(t *testing.T) {
+		expectLocked(appliedRows().AddRow(1, "create_users", appliedAt))
+		mock.ExpectBegin()
+		mock.ExpectExec("ALTER TABLE users ADD COLUMN name TEXT;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("INSERT INTO schema_migrations (version, name) VALUES ($1, $2);").WithArgs(2, "users_name").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock()
+
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(applied) != 1 || applied[0].Version != 2 {
+			t.Errorf("expected migration 2 applied, got %+v", applied)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("up rolls back a failed migration", func(t *testing.T) {
+		expectLocked(appliedRows())
+		mock.ExpectBegin()
+		mock.ExpectExec("CREATE TABLE users (id SERIAL);").WillReturnError(errors.New("relation already exists"))
+		mock.ExpectRollback()
+		expectUnlock()
+
+		applied, err := migrator.Up(ctx)
+		if err == nil {
+			t.Error("expected an error but got none")
+		}
+		if len(applied) != 0 {
+			t.Errorf("expected no migration applied, got %+v", applied)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("down rolls back the latest migrations", func(t *testing.T) {
+		expectLocked(appliedRows().AddRow(1, "create_users", appliedAt).AddRow(2, "users_name", appliedAt))
+		mock.ExpectBegin()
+		mock.ExpectExec("ALTER TABLE users DROP COLUMN name;").WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("DELETE FROM schema_migrations WHERE version = $1;").WithArgs(2).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		expectUnlock()
+
+		rolledBack, err := migrator.Down(ctx, 1)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(rolledBack) != 1 || rolledBack[0].Version != 2 {
+			t.Errorf("expected migration 2 rolled back, got %+v", rolledBack)
+		}
+		assertExpectations(t)
+	})
+
+	t.Run("down refuses unknown migrations", func(t *testing.T) {
+		expectLocked(appliedRows().AddRow(1, "create_users", appliedAt).AddRow(3, "users_email", appliedAt))
+		expectUnlock()
+
+		if _, err := migrator.Down(ctx, 1); !errors.Is(err, ErrUnknownMigration) {
+			t.Errorf("expected error: %v, got %v", ErrUnknownMigration, err)
+		}
+		assertExpectations(t)
+	})
+
+	expectStatus := func(exists bool) {
+		mock.ExpectQuery("SELECT to_regclass('schema_migrations') IS NOT NULL;").
+			WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(exists))
+	}
+
+	t.Run("status", func(t *testing.T) {
+		expectStatus(true)
+		mock.ExpectQuery("SELECT version, name, applied_at FROM schema_migrations ORDER BY version;").
+			WillReturnRows(appliedRows().AddRow(1, "create_users", appliedAt).AddRow(3, "users_email", appliedAt))
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		want := []struct {
+			version int
+			applied bool
+		}{{1, true}, {2, false}, {3, true}}
+		if len(statuses) != len(want) {
+			t.Fatalf("expected %v statuses, got %+v", len(want), statuses)
+		}
+		for i, w := range want {
+			if statuses[i].Version != w.version || statuses[i].Applied != w.applied {
+				t.Errorf("expected version %v applied %v, got %+v", w.version, w.applied, statuses[i])
+			}
+		}
+		assertExpectations(t)
+	})
+	t.Run("status without schema_migrations", func(t *testing.T) {
+		expectStatus(false)
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			t.Fatalf("expected no error but got %v", err)
+		}
+		if len(statuses) != 2 || statuses[0].Applied || statuses[1].Applied {
+			t.Errorf("expected every migration pending, got %+v", statuses)
+		}
+		assertExpectations(t)
+	})
+}
@@ -1,3 +1,4 @@
+-- IF NOT EXISTS adopts databases created by the former schema.sql
 CREATE TABLE IF NOT EXISTS users (
     id SERIAL PRIMARY KEY,
     name VARCHAR(75),
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS users_name_id_idx;
+DROP INDEX IF EXISTS users_surname_name_id_idx;
@@ -0,0 +1,3 @@
+-- keyset indexes for the sortable columns, id is the tie breaker
+CREATE INDEX IF NOT EXISTS users_surname_name_id_idx ON users (surname, name, id);
+CREATE INDEX IF NOT EXISTS users_name_id_idx ON users (name, id);
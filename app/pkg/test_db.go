@@ -41,7 +41,7 @@ func (d *DbAttributes) PgTestContainerSetup(ctx context.Context) *postgres.Postg
 }
 
 // DbSetup establishes a database connection and applies schema migrations.
-func (d *DbAttributes) DbSetup(ctx context.Context, ctr *postgres.PostgresContainer, schemaFileDir string) *sql.DB {
+func (d *DbAttributes) DbSetup(ctx context.Context, ctr *postgres.PostgresContainer) *sql.DB {
 	host, err := ctr.Host(ctx)
 	if err != nil {
 		log.Fatalf("Failed to get container host: %v", err)
@@ -72,12 +72,12 @@ func (d *DbAttributes) DbSetup(ctx context.Context, ctr *postgres.PostgresContai
 
 	log.Println("Db setup completed.")
 
-	query, err := ReadFile(schemaFileDir)
+	migrator, err := NewMigrator(db)
 	if err != nil {
-		log.Fatalf("Failed to read sql file: %v", err)
+		log.Fatalf("Failed to load migrations: %v", err)
 	}
 
-	if err := RunMigration(db, query); err != nil {
+	if _, err := migrator.Up(ctx); err != nil {
 		log.Fatalf("Failed to run migration: %v", err)
 	}
 